@@ -1,6 +1,8 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -10,12 +12,31 @@ import (
 // WildflySpec defines the desired state of Wildfly
 // +k8s:openapi-gen=true
 type WildflySpec struct {
-	Size     int32              `json:"size"`
-	Image    string             `json:"image"`
-	Version  string             `json:"version"`
-	Cmd      []string           `json:"cmd"`
-	Ports    []WildflyPortProto `json:"ports"`
-	NodePort bool               `json:"nodePort"`
+	Size                  int32                         `json:"size"`
+	Image                 string                        `json:"image"`
+	Version               string                        `json:"version"`
+	Cmd                   []string                      `json:"cmd"`
+	Ports                 []WildflyPortProto            `json:"ports"`
+	NodePort              bool                          `json:"nodePort"`
+	Config                *WildflyConfig                `json:"config,omitempty"`
+	Datasources           []WildflyDatasourceDefinition `json:"datasources,omitempty"`
+	Management            *WildflyManagement            `json:"management,omitempty"`
+	Probes                *WildflyProbes                `json:"probes,omitempty"`
+	Mode                  WildflyMode                   `json:"mode,omitempty"`
+	Storage               *WildflyStorage               `json:"storage,omitempty"`
+	SuspendTimeoutSeconds int32                         `json:"suspendTimeoutSeconds,omitempty"`
+	Clustering            *WildflyClustering            `json:"clustering,omitempty"`
+	Expose                *WildflyExpose                `json:"expose,omitempty"`
+	HTTPS                 *WildflyHTTPS                 `json:"https,omitempty"`
+	Applications          []WildflyApp                  `json:"applications,omitempty"`
+	Drivers               []WildflyDriver               `json:"drivers,omitempty"`
+	CliScripts            []string                      `json:"cliScripts,omitempty"`
+	Env                   []corev1.EnvVar               `json:"env,omitempty"`
+	EnvFrom               []corev1.EnvFromSource        `json:"envFrom,omitempty"`
+	SystemProperties      map[string]string             `json:"systemProperties,omitempty"`
+	Resources             corev1.ResourceRequirements   `json:"resources,omitempty"`
+	JVM                   *WildflyJVM                   `json:"jvm,omitempty"`
+	Monitoring            *WildflyMonitoring            `json:"monitoring,omitempty"`
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "operator-sdk generate k8s" to regenerate code after modifying this file
 	// Add custom validation using kubebuilder tags: https://book.kubebuilder.io/beyond_basics/generating_crd.html
@@ -27,14 +48,321 @@ type WildflyPortProto struct {
 	Protocol string `json:"protocol"`
 }
 
+// WildflyConfig references a ConfigMap whose keys are mounted as files under
+// standalone/configuration, e.g. standalone.xml or logging.properties
+type WildflyConfig struct {
+	ConfigMap string `json:"configMap"`
+}
+
+// WildflyDatasourceDefinition defines a datasource of the Wildfly server. Credentials are read
+// from the username and password keys of CredentialsSecret.
+type WildflyDatasourceDefinition struct {
+	Name              string `json:"name"`
+	JNDIName          string `json:"jndiName"`
+	Driver            string `json:"driver"`
+	ConnectionURL     string `json:"connectionURL"`
+	MinPoolSize       int32  `json:"minPoolSize,omitempty"`
+	MaxPoolSize       int32  `json:"maxPoolSize,omitempty"`
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+}
+
+// WildflyManagement configures the access of the operator to the HTTP management interface
+// of the servers. CredentialsSecret holds the username and password keys of a management user,
+// which is added to the ManagementRealm of the servers. Expose publishes the management port
+// on the Service.
+type WildflyManagement struct {
+	CredentialsSecret string              `json:"credentialsSecret"`
+	ReloadPolicy      WildflyReloadPolicy `json:"reloadPolicy,omitempty"`
+	Expose            bool                `json:"expose,omitempty"`
+}
+
+// WildflyReloadPolicy defines what the operator does with servers reporting reload-required
+type WildflyReloadPolicy string
+
+const (
+	// ReloadNever leaves the reload of the servers to the user. This is the default.
+	ReloadNever WildflyReloadPolicy = "Never"
+	// ReloadAutomatic makes the operator issue a :reload on servers reporting reload-required,
+	// one at a time and while every replica is ready
+	ReloadAutomatic WildflyReloadPolicy = "Automatic"
+)
+
+// WildflyProbes configures the liveness and readiness probes of the wildfly container, which
+// get the default HTTP probes when it is not set. StartupTimeoutSeconds is the time granted to the server to boot before the liveness probe
+// starts, to avoid restarting pods with slow deployments.
+type WildflyProbes struct {
+	Handler               WildflyProbeHandler `json:"handler,omitempty"`
+	StartupTimeoutSeconds int32               `json:"startupTimeoutSeconds,omitempty"`
+	Liveness              *WildflyProbe       `json:"liveness,omitempty"`
+	Readiness             *WildflyProbe       `json:"readiness,omitempty"`
+}
+
+// WildflyProbeHandler selects how the probes check the server
+type WildflyProbeHandler string
+
+const (
+	// ProbeHTTP queries the /health/live and /health/ready endpoints of the management
+	// interface. This is the default.
+	ProbeHTTP WildflyProbeHandler = "HTTP"
+	// ProbeExec runs jboss-cli.sh in the container, for images without the health endpoints
+	ProbeExec WildflyProbeHandler = "Exec"
+	// ProbeNone disables the probes
+	ProbeNone WildflyProbeHandler = "None"
+)
+
+// WildflyProbe tunes the timings of a probe, zero values are replaced by the defaults
+type WildflyProbe struct {
+	InitialDelaySeconds int32 `json:"initialDelaySeconds,omitempty"`
+	PeriodSeconds       int32 `json:"periodSeconds,omitempty"`
+	TimeoutSeconds      int32 `json:"timeoutSeconds,omitempty"`
+	FailureThreshold    int32 `json:"failureThreshold,omitempty"`
+}
+
+// WildflyMode selects the kind of workload running the servers
+type WildflyMode string
+
+const (
+	// ModeDeployment runs the servers in a Deployment. This is the default.
+	ModeDeployment WildflyMode = "Deployment"
+	// ModeStatefulSet runs the servers in a StatefulSet, giving each pod a stable identity
+	// and its own persistent volumes, e.g. for the transaction log of XA transactions.
+	ModeStatefulSet WildflyMode = "StatefulSet"
+)
+
+// WildflyStorage defines the persistent volumes of the servers for the standalone/data and
+// standalone/log directories. In StatefulSet mode each pod gets its own claims, in Deployment
+// mode the claims are shared by the pods: Data then limits the Deployment to one replica, and
+// each pod writes its logs in its own directory of Logs. FSGroup is the group of the pods
+// owning the volumes, so that the jboss user can write to freshly provisioned ones: it
+// defaults to the jboss group 1000, except on OpenShift where the security context constraint
+// of the pod assigns it.
+type WildflyStorage struct {
+	Data    *WildflyVolumeClaim `json:"data,omitempty"`
+	Logs    *WildflyVolumeClaim `json:"logs,omitempty"`
+	FSGroup *int64              `json:"fsGroup,omitempty"`
+}
+
+// WildflyVolumeClaim defines a persistent volume claim. The access mode defaults to
+// ReadWriteOnce.
+type WildflyVolumeClaim struct {
+	Size             string                              `json:"size"`
+	StorageClassName *string                             `json:"storageClassName,omitempty"`
+	AccessModes      []corev1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
+}
+
+// WildflyClustering makes the servers run the HA profile and form a JGroups cluster.
+// ClusterDomain is the DNS domain of the Kubernetes cluster, cluster.local by default.
+type WildflyClustering struct {
+	Discovery     WildflyDiscovery `json:"discovery,omitempty"`
+	ClusterDomain string           `json:"clusterDomain,omitempty"`
+}
+
+// WildflyDiscovery selects the JGroups protocol used by the members to find each other
+type WildflyDiscovery string
+
+const (
+	// DiscoveryDNSPing resolves the members from the headless Service. This is the default.
+	DiscoveryDNSPing WildflyDiscovery = "DNS_PING"
+	// DiscoveryKubePing lists the members through the Kubernetes API, the operator creates
+	// the service account allowed to list the pods
+	DiscoveryKubePing WildflyDiscovery = "KUBE_PING"
+)
+
+// WildflyExpose exposes an HTTP port of the servers outside of the cluster, through an
+// OpenShift Route when the Route API is available and an Ingress otherwise. Port defaults to
+// 8080, or to the HTTPS port 8443 for a Route with the reencrypt or passthrough termination.
+// TLSSecret is a kubernetes.io/tls Secret: an Ingress references it, a Route inlines its
+// tls.crt, tls.key and ca.crt keys. Termination only applies to a Route, edge by default when
+// a TLS Secret is set; reencrypt and passthrough need Spec.HTTPS, whose ca.crt key is the
+// destination CA certificate of a reencrypt Route.
+type WildflyExpose struct {
+	Host        string                `json:"host,omitempty"`
+	Path        string                `json:"path,omitempty"`
+	Port        int32                 `json:"port,omitempty"`
+	TLSSecret   string                `json:"tlsSecret,omitempty"`
+	Termination WildflyTLSTermination `json:"termination,omitempty"`
+	Annotations map[string]string     `json:"annotations,omitempty"`
+}
+
+// WildflyTLSTermination is the TLS termination of a Route
+type WildflyTLSTermination string
+
+const (
+	// TerminationEdge terminates TLS at the router, the servers are reached over HTTP
+	TerminationEdge WildflyTLSTermination = "edge"
+	// TerminationReencrypt terminates TLS at the router which opens a new TLS connection to
+	// the servers
+	TerminationReencrypt WildflyTLSTermination = "reencrypt"
+	// TerminationPassthrough sends the encrypted traffic straight to the servers
+	TerminationPassthrough WildflyTLSTermination = "passthrough"
+)
+
+// WildflyHTTPS references the kubernetes.io/tls Secret holding the certificate and the key of
+// the HTTPS listener. The operator converts it to a PKCS12 keystore loaded by Elytron.
+type WildflyHTTPS struct {
+	Secret string `json:"secret"`
+}
+
+// WildflyApp is an application copied into standalone/deployments from exactly one source: an
+// HTTP URL, checked against its SHA256 checksum when set, a key of a ConfigMap or Secret for
+// small artifacts (Key defaults to Name), or the file at Path in an Image, copied by an init
+// container running the image. Name is the file name of the deployment, e.g. app.war.
+type WildflyApp struct {
+	Name      string `json:"name"`
+	URL       string `json:"url,omitempty"`
+	SHA256    string `json:"sha256,omitempty"`
+	ConfigMap string `json:"configMap,omitempty"`
+	Secret    string `json:"secret,omitempty"`
+	Key       string `json:"key,omitempty"`
+	Image     string `json:"image,omitempty"`
+	Path      string `json:"path,omitempty"`
+}
+
+// WildflyDriver is a JDBC driver installed as the JBoss module named Module and registered in
+// the datasources subsystem under Name. The jar is resolved from the Maven coordinates
+// groupId:artifactId:version in Repository, which defaults to Maven Central, or copied from
+// Path in an Image by an init container running the image. DriverClass and XADatasourceClass
+// are only needed when the jar does not register its driver with the service loader.
+type WildflyDriver struct {
+	Name              string `json:"name"`
+	Module            string `json:"module"`
+	Maven             string `json:"maven,omitempty"`
+	Repository        string `json:"repository,omitempty"`
+	Image             string `json:"image,omitempty"`
+	Path              string `json:"path,omitempty"`
+	DriverClass       string `json:"driverClass,omitempty"`
+	XADatasourceClass string `json:"xaDatasourceClass,omitempty"`
+}
+
+// WildflyJVM tunes the JVM of the servers. The maximum heap is HeapPercentage of the memory
+// limit of the container, from 1 to 100 with 0 meaning the default of 50, and must leave room
+// for MaxMetaspaceSize, 256Mi by default. Args are appended to the generated options.
+type WildflyJVM struct {
+	HeapPercentage   int32              `json:"heapPercentage,omitempty"`
+	GC               WildflyGC          `json:"gc,omitempty"`
+	MaxMetaspaceSize *resource.Quantity `json:"maxMetaspaceSize,omitempty"`
+	Args             []string           `json:"args,omitempty"`
+}
+
+// WildflyGC is the garbage collector of the JVM
+type WildflyGC string
+
+const (
+	// GCG1 selects the G1 garbage collector
+	GCG1 WildflyGC = "G1"
+	// GCParallel selects the parallel garbage collector
+	GCParallel WildflyGC = "Parallel"
+	// GCSerial selects the serial garbage collector, for containers limited to one CPU
+	GCSerial WildflyGC = "Serial"
+)
+
+// WildflyMonitoring enables the /metrics endpoint of the management interface of the servers
+// and publishes it on a dedicated metrics Service. When the Prometheus Operator API is
+// available, a ServiceMonitor scrapes it every Interval, the interval of Prometheus by default.
+// Labels are set on the ServiceMonitor, e.g. to match the serviceMonitorSelector of a
+// Prometheus.
+type WildflyMonitoring struct {
+	Interval string            `json:"interval,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+}
+
 // WildflyStatus defines the observed state of Wildfly
 // +k8s:openapi-gen=true
 type WildflyStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
+	Mode          WildflyMode        `json:"mode,omitempty"`
+	Replicas      int32              `json:"replicas"`
+	ReadyReplicas int32              `json:"readyReplicas"`
+	Pods          []string           `json:"pods,omitempty"`
+	Image         string             `json:"image,omitempty"`
+	ClusterIP     string             `json:"clusterIP,omitempty"`
+	NodePorts     []WildflyNodePort  `json:"nodePorts,omitempty"`
+	Conditions    []WildflyCondition `json:"conditions,omitempty"`
+	Servers       []WildflyServer    `json:"servers,omitempty"`
+	ScaleDown     *WildflyScaleDown  `json:"scaleDown,omitempty"`
+	Recoveries    []WildflyDrain     `json:"recoveries,omitempty"`
+	Host          string             `json:"host,omitempty"`
+	Applications  []WildflyAppStatus `json:"applications,omitempty"`
 	// Important: Run "operator-sdk generate k8s" to regenerate code after modifying this file
 	// Add custom validation using kubebuilder tags: https://book.kubebuilder.io/beyond_basics/generating_crd.html
 }
 
+// WildflyAppStatus is the deployment status of an application across the servers
+type WildflyAppStatus struct {
+	Name    string               `json:"name"`
+	Status  WildflyAppDeployment `json:"status"`
+	Message string               `json:"message,omitempty"`
+}
+
+// WildflyAppDeployment is the deployment status of an application
+type WildflyAppDeployment string
+
+const (
+	// AppDeployed is reported when the application is deployed on every running server
+	AppDeployed WildflyAppDeployment = "Deployed"
+	// AppPending is reported while the application is not deployed on every server yet
+	AppPending WildflyAppDeployment = "Pending"
+	// AppFailed is reported when the deployment failed on at least one server
+	AppFailed WildflyAppDeployment = "Failed"
+	// AppUnknown is reported when the management interface is not enabled
+	AppUnknown WildflyAppDeployment = "Unknown"
+)
+
+// WildflyServer is the runtime state of the server running in a pod, as reported by the
+// management interface
+type WildflyServer struct {
+	Pod         string `json:"pod"`
+	ServerState string `json:"serverState"`
+	RunningMode string `json:"runningMode,omitempty"`
+	Message     string `json:"message,omitempty"`
+}
+
+// WildflyScaleDown reports the progress of a scale down of the StatefulSet. The pods with the
+// highest ordinals are suspended and their transaction log drained before the replicas are
+// lowered from From to To.
+type WildflyScaleDown struct {
+	From int32          `json:"from"`
+	To   int32          `json:"to"`
+	Pods []WildflyDrain `json:"pods,omitempty"`
+}
+
+// WildflyDrain is the draining state of the transaction log of a server. Pod is either a pod
+// being scaled down or a recovery pod started against an orphaned volume.
+type WildflyDrain struct {
+	Pod          string `json:"pod"`
+	State        string `json:"state"`
+	Transactions int32  `json:"transactions"`
+	Message      string `json:"message,omitempty"`
+}
+
+// WildflyNodePort maps a Service port to the node port allocated for it
+type WildflyNodePort struct {
+	Port     int32 `json:"port"`
+	NodePort int32 `json:"nodePort"`
+}
+
+// WildflyConditionType is the type of a Wildfly condition
+type WildflyConditionType string
+
+// Condition types reported in the Wildfly status
+const (
+	// WildflyAvailable means the minimum number of replicas is ready to serve requests
+	WildflyAvailable WildflyConditionType = "Available"
+	// WildflyProgressing means a rollout of the managed pods is in progress or completed
+	WildflyProgressing WildflyConditionType = "Progressing"
+	// WildflyDegraded means the pods could not be created, the rollout is stuck or the
+	// configuration of a server failed to be prepared
+	WildflyDegraded WildflyConditionType = "Degraded"
+)
+
+// WildflyCondition describes the state of a Wildfly at a certain point
+type WildflyCondition struct {
+	Type               WildflyConditionType   `json:"type"`
+	Status             corev1.ConditionStatus `json:"status"`
+	LastTransitionTime metav1.Time            `json:"lastTransitionTime,omitempty"`
+	Reason             string                 `json:"reason,omitempty"`
+	Message            string                 `json:"message,omitempty"`
+}
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // Wildfly is the Schema for the wildflies API
@@ -0,0 +1,88 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WildflyApplicationSpec defines the desired state of WildflyApplication. The artifact served
+// at URL, checked against its SHA256 checksum when set, is deployed at runtime on the servers
+// of the Wildfly named by Wildfly through their management interface. DeploymentName defaults
+// to the file name of the URL. With RollbackOnFailure, an artifact failing to deploy is
+// replaced by the previous one.
+// +k8s:openapi-gen=true
+type WildflyApplicationSpec struct {
+	Wildfly           string `json:"wildfly"`
+	URL               string `json:"url"`
+	Sha256            string `json:"sha256,omitempty"`
+	Version           string `json:"version,omitempty"`
+	DeploymentName    string `json:"deploymentName,omitempty"`
+	RollbackOnFailure bool   `json:"rollbackOnFailure,omitempty"`
+}
+
+// WildflyApplicationStatus defines the observed state of WildflyApplication
+// +k8s:openapi-gen=true
+type WildflyApplicationStatus struct {
+	Phase    WildflyApplicationPhase    `json:"phase,omitempty"`
+	Message  string                     `json:"message,omitempty"`
+	Current  *WildflyArtifact           `json:"current,omitempty"`
+	Previous *WildflyArtifact           `json:"previous,omitempty"`
+	Failed   *WildflyArtifact           `json:"failed,omitempty"`
+	Servers  []WildflyApplicationServer `json:"servers,omitempty"`
+}
+
+// WildflyApplicationPhase summarizes the deployment of the application on the servers
+type WildflyApplicationPhase string
+
+const (
+	// ApplicationPending is reported while the artifact is not deployed on every server
+	ApplicationPending WildflyApplicationPhase = "Pending"
+	// ApplicationDeployed is reported when the artifact is enabled on every running server
+	ApplicationDeployed WildflyApplicationPhase = "Deployed"
+	// ApplicationFailed is reported when the artifact failed to deploy on a server
+	ApplicationFailed WildflyApplicationPhase = "Failed"
+	// ApplicationRolledBack is reported when the artifact failed to deploy and the previous
+	// one was deployed back
+	ApplicationRolledBack WildflyApplicationPhase = "RolledBack"
+)
+
+// WildflyArtifact identifies a version of the artifact of an application. Hash is the SHA-1
+// hash of the content in the content repository of the servers.
+type WildflyArtifact struct {
+	DeploymentName string `json:"deploymentName"`
+	URL            string `json:"url"`
+	SHA256         string `json:"sha256,omitempty"`
+	Version        string `json:"version,omitempty"`
+	Hash           string `json:"hash,omitempty"`
+}
+
+// WildflyApplicationServer is the state of the deployment on the server running in a pod
+type WildflyApplicationServer struct {
+	Pod     string `json:"pod"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// WildflyApplication is the Schema for the wildflyapplications API
+// +k8s:openapi-gen=true
+type WildflyApplication struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   WildflyApplicationSpec   `json:"spec,omitempty"`
+	Status WildflyApplicationStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// WildflyApplicationList contains a list of WildflyApplication
+type WildflyApplicationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WildflyApplication `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WildflyApplication{}, &WildflyApplicationList{})
+}
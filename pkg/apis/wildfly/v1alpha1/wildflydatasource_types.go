@@ -0,0 +1,54 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WildflyDatasourceSpec defines the desired state of WildflyDatasource. The datasource is
+// created at runtime through the management interface on the servers of each Wildfly named in
+// Wildflies, which must be in the same namespace.
+// +k8s:openapi-gen=true
+type WildflyDatasourceSpec struct {
+	Wildflies                   []string `json:"wildflies"`
+	WildflyDatasourceDefinition `json:",inline"`
+}
+
+// WildflyDatasourceStatus defines the observed state of WildflyDatasource
+// +k8s:openapi-gen=true
+type WildflyDatasourceStatus struct {
+	Servers []WildflyDatasourceServer `json:"servers,omitempty"`
+}
+
+// WildflyDatasourceServer is the state of the datasource on the server running in a pod.
+// Connected reports the result of the last test-connection-in-pool.
+type WildflyDatasourceServer struct {
+	Wildfly   string `json:"wildfly"`
+	Pod       string `json:"pod,omitempty"`
+	Connected bool   `json:"connected"`
+	Message   string `json:"message,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// WildflyDatasource is the Schema for the wildflydatasources API
+// +k8s:openapi-gen=true
+type WildflyDatasource struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   WildflyDatasourceSpec   `json:"spec,omitempty"`
+	Status WildflyDatasourceStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// WildflyDatasourceList contains a list of WildflyDatasource
+type WildflyDatasourceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WildflyDatasource `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WildflyDatasource{}, &WildflyDatasourceList{})
+}
@@ -5,6 +5,7 @@
 package v1alpha1
 
 import (
+	v1 "k8s.io/api/core/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -14,7 +15,7 @@ func (in *Wildfly) DeepCopyInto(out *Wildfly) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -36,6 +37,465 @@ func (in *Wildfly) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WildflyApp) DeepCopyInto(out *WildflyApp) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WildflyApp.
+func (in *WildflyApp) DeepCopy() *WildflyApp {
+	if in == nil {
+		return nil
+	}
+	out := new(WildflyApp)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WildflyAppStatus) DeepCopyInto(out *WildflyAppStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WildflyAppStatus.
+func (in *WildflyAppStatus) DeepCopy() *WildflyAppStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WildflyAppStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WildflyApplication) DeepCopyInto(out *WildflyApplication) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WildflyApplication.
+func (in *WildflyApplication) DeepCopy() *WildflyApplication {
+	if in == nil {
+		return nil
+	}
+	out := new(WildflyApplication)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WildflyApplication) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WildflyApplicationList) DeepCopyInto(out *WildflyApplicationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]WildflyApplication, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WildflyApplicationList.
+func (in *WildflyApplicationList) DeepCopy() *WildflyApplicationList {
+	if in == nil {
+		return nil
+	}
+	out := new(WildflyApplicationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WildflyApplicationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WildflyApplicationServer) DeepCopyInto(out *WildflyApplicationServer) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WildflyApplicationServer.
+func (in *WildflyApplicationServer) DeepCopy() *WildflyApplicationServer {
+	if in == nil {
+		return nil
+	}
+	out := new(WildflyApplicationServer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WildflyApplicationSpec) DeepCopyInto(out *WildflyApplicationSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WildflyApplicationSpec.
+func (in *WildflyApplicationSpec) DeepCopy() *WildflyApplicationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WildflyApplicationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WildflyApplicationStatus) DeepCopyInto(out *WildflyApplicationStatus) {
+	*out = *in
+	if in.Current != nil {
+		in, out := &in.Current, &out.Current
+		*out = new(WildflyArtifact)
+		**out = **in
+	}
+	if in.Previous != nil {
+		in, out := &in.Previous, &out.Previous
+		*out = new(WildflyArtifact)
+		**out = **in
+	}
+	if in.Failed != nil {
+		in, out := &in.Failed, &out.Failed
+		*out = new(WildflyArtifact)
+		**out = **in
+	}
+	if in.Servers != nil {
+		in, out := &in.Servers, &out.Servers
+		*out = make([]WildflyApplicationServer, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WildflyApplicationStatus.
+func (in *WildflyApplicationStatus) DeepCopy() *WildflyApplicationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WildflyApplicationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WildflyArtifact) DeepCopyInto(out *WildflyArtifact) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WildflyArtifact.
+func (in *WildflyArtifact) DeepCopy() *WildflyArtifact {
+	if in == nil {
+		return nil
+	}
+	out := new(WildflyArtifact)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WildflyClustering) DeepCopyInto(out *WildflyClustering) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WildflyClustering.
+func (in *WildflyClustering) DeepCopy() *WildflyClustering {
+	if in == nil {
+		return nil
+	}
+	out := new(WildflyClustering)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WildflyCondition) DeepCopyInto(out *WildflyCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WildflyCondition.
+func (in *WildflyCondition) DeepCopy() *WildflyCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(WildflyCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WildflyConfig) DeepCopyInto(out *WildflyConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WildflyConfig.
+func (in *WildflyConfig) DeepCopy() *WildflyConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(WildflyConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WildflyDatasource) DeepCopyInto(out *WildflyDatasource) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WildflyDatasource.
+func (in *WildflyDatasource) DeepCopy() *WildflyDatasource {
+	if in == nil {
+		return nil
+	}
+	out := new(WildflyDatasource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WildflyDatasource) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WildflyDatasourceDefinition) DeepCopyInto(out *WildflyDatasourceDefinition) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WildflyDatasourceDefinition.
+func (in *WildflyDatasourceDefinition) DeepCopy() *WildflyDatasourceDefinition {
+	if in == nil {
+		return nil
+	}
+	out := new(WildflyDatasourceDefinition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WildflyDatasourceList) DeepCopyInto(out *WildflyDatasourceList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]WildflyDatasource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WildflyDatasourceList.
+func (in *WildflyDatasourceList) DeepCopy() *WildflyDatasourceList {
+	if in == nil {
+		return nil
+	}
+	out := new(WildflyDatasourceList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WildflyDatasourceList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WildflyDatasourceServer) DeepCopyInto(out *WildflyDatasourceServer) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WildflyDatasourceServer.
+func (in *WildflyDatasourceServer) DeepCopy() *WildflyDatasourceServer {
+	if in == nil {
+		return nil
+	}
+	out := new(WildflyDatasourceServer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WildflyDatasourceSpec) DeepCopyInto(out *WildflyDatasourceSpec) {
+	*out = *in
+	if in.Wildflies != nil {
+		in, out := &in.Wildflies, &out.Wildflies
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.WildflyDatasourceDefinition = in.WildflyDatasourceDefinition
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WildflyDatasourceSpec.
+func (in *WildflyDatasourceSpec) DeepCopy() *WildflyDatasourceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WildflyDatasourceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WildflyDatasourceStatus) DeepCopyInto(out *WildflyDatasourceStatus) {
+	*out = *in
+	if in.Servers != nil {
+		in, out := &in.Servers, &out.Servers
+		*out = make([]WildflyDatasourceServer, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WildflyDatasourceStatus.
+func (in *WildflyDatasourceStatus) DeepCopy() *WildflyDatasourceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WildflyDatasourceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WildflyDrain) DeepCopyInto(out *WildflyDrain) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WildflyDrain.
+func (in *WildflyDrain) DeepCopy() *WildflyDrain {
+	if in == nil {
+		return nil
+	}
+	out := new(WildflyDrain)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WildflyDriver) DeepCopyInto(out *WildflyDriver) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WildflyDriver.
+func (in *WildflyDriver) DeepCopy() *WildflyDriver {
+	if in == nil {
+		return nil
+	}
+	out := new(WildflyDriver)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WildflyExpose) DeepCopyInto(out *WildflyExpose) {
+	*out = *in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WildflyExpose.
+func (in *WildflyExpose) DeepCopy() *WildflyExpose {
+	if in == nil {
+		return nil
+	}
+	out := new(WildflyExpose)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WildflyHTTPS) DeepCopyInto(out *WildflyHTTPS) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WildflyHTTPS.
+func (in *WildflyHTTPS) DeepCopy() *WildflyHTTPS {
+	if in == nil {
+		return nil
+	}
+	out := new(WildflyHTTPS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WildflyJVM) DeepCopyInto(out *WildflyJVM) {
+	*out = *in
+	if in.MaxMetaspaceSize != nil {
+		in, out := &in.MaxMetaspaceSize, &out.MaxMetaspaceSize
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.Args != nil {
+		in, out := &in.Args, &out.Args
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WildflyJVM.
+func (in *WildflyJVM) DeepCopy() *WildflyJVM {
+	if in == nil {
+		return nil
+	}
+	out := new(WildflyJVM)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WildflyList) DeepCopyInto(out *WildflyList) {
 	*out = *in
@@ -69,6 +529,61 @@ func (in *WildflyList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WildflyManagement) DeepCopyInto(out *WildflyManagement) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WildflyManagement.
+func (in *WildflyManagement) DeepCopy() *WildflyManagement {
+	if in == nil {
+		return nil
+	}
+	out := new(WildflyManagement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WildflyMonitoring) DeepCopyInto(out *WildflyMonitoring) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WildflyMonitoring.
+func (in *WildflyMonitoring) DeepCopy() *WildflyMonitoring {
+	if in == nil {
+		return nil
+	}
+	out := new(WildflyMonitoring)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WildflyNodePort) DeepCopyInto(out *WildflyNodePort) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WildflyNodePort.
+func (in *WildflyNodePort) DeepCopy() *WildflyNodePort {
+	if in == nil {
+		return nil
+	}
+	out := new(WildflyNodePort)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WildflyPortProto) DeepCopyInto(out *WildflyPortProto) {
 	*out = *in
@@ -85,6 +600,85 @@ func (in *WildflyPortProto) DeepCopy() *WildflyPortProto {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WildflyProbe) DeepCopyInto(out *WildflyProbe) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WildflyProbe.
+func (in *WildflyProbe) DeepCopy() *WildflyProbe {
+	if in == nil {
+		return nil
+	}
+	out := new(WildflyProbe)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WildflyProbes) DeepCopyInto(out *WildflyProbes) {
+	*out = *in
+	if in.Liveness != nil {
+		in, out := &in.Liveness, &out.Liveness
+		*out = new(WildflyProbe)
+		**out = **in
+	}
+	if in.Readiness != nil {
+		in, out := &in.Readiness, &out.Readiness
+		*out = new(WildflyProbe)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WildflyProbes.
+func (in *WildflyProbes) DeepCopy() *WildflyProbes {
+	if in == nil {
+		return nil
+	}
+	out := new(WildflyProbes)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WildflyScaleDown) DeepCopyInto(out *WildflyScaleDown) {
+	*out = *in
+	if in.Pods != nil {
+		in, out := &in.Pods, &out.Pods
+		*out = make([]WildflyDrain, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WildflyScaleDown.
+func (in *WildflyScaleDown) DeepCopy() *WildflyScaleDown {
+	if in == nil {
+		return nil
+	}
+	out := new(WildflyScaleDown)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WildflyServer) DeepCopyInto(out *WildflyServer) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WildflyServer.
+func (in *WildflyServer) DeepCopy() *WildflyServer {
+	if in == nil {
+		return nil
+	}
+	out := new(WildflyServer)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WildflySpec) DeepCopyInto(out *WildflySpec) {
 	*out = *in
@@ -98,6 +692,93 @@ func (in *WildflySpec) DeepCopyInto(out *WildflySpec) {
 		*out = make([]WildflyPortProto, len(*in))
 		copy(*out, *in)
 	}
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = new(WildflyConfig)
+		**out = **in
+	}
+	if in.Datasources != nil {
+		in, out := &in.Datasources, &out.Datasources
+		*out = make([]WildflyDatasourceDefinition, len(*in))
+		copy(*out, *in)
+	}
+	if in.Management != nil {
+		in, out := &in.Management, &out.Management
+		*out = new(WildflyManagement)
+		**out = **in
+	}
+	if in.Probes != nil {
+		in, out := &in.Probes, &out.Probes
+		*out = new(WildflyProbes)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Storage != nil {
+		in, out := &in.Storage, &out.Storage
+		*out = new(WildflyStorage)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Clustering != nil {
+		in, out := &in.Clustering, &out.Clustering
+		*out = new(WildflyClustering)
+		**out = **in
+	}
+	if in.Expose != nil {
+		in, out := &in.Expose, &out.Expose
+		*out = new(WildflyExpose)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HTTPS != nil {
+		in, out := &in.HTTPS, &out.HTTPS
+		*out = new(WildflyHTTPS)
+		**out = **in
+	}
+	if in.Applications != nil {
+		in, out := &in.Applications, &out.Applications
+		*out = make([]WildflyApp, len(*in))
+		copy(*out, *in)
+	}
+	if in.Drivers != nil {
+		in, out := &in.Drivers, &out.Drivers
+		*out = make([]WildflyDriver, len(*in))
+		copy(*out, *in)
+	}
+	if in.CliScripts != nil {
+		in, out := &in.CliScripts, &out.CliScripts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]v1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.EnvFrom != nil {
+		in, out := &in.EnvFrom, &out.EnvFrom
+		*out = make([]v1.EnvFromSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SystemProperties != nil {
+		in, out := &in.SystemProperties, &out.SystemProperties
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.JVM != nil {
+		in, out := &in.JVM, &out.JVM
+		*out = new(WildflyJVM)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Monitoring != nil {
+		in, out := &in.Monitoring, &out.Monitoring
+		*out = new(WildflyMonitoring)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -114,6 +795,43 @@ func (in *WildflySpec) DeepCopy() *WildflySpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WildflyStatus) DeepCopyInto(out *WildflyStatus) {
 	*out = *in
+	if in.Pods != nil {
+		in, out := &in.Pods, &out.Pods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodePorts != nil {
+		in, out := &in.NodePorts, &out.NodePorts
+		*out = make([]WildflyNodePort, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]WildflyCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Servers != nil {
+		in, out := &in.Servers, &out.Servers
+		*out = make([]WildflyServer, len(*in))
+		copy(*out, *in)
+	}
+	if in.ScaleDown != nil {
+		in, out := &in.ScaleDown, &out.ScaleDown
+		*out = new(WildflyScaleDown)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Recoveries != nil {
+		in, out := &in.Recoveries, &out.Recoveries
+		*out = make([]WildflyDrain, len(*in))
+		copy(*out, *in)
+	}
+	if in.Applications != nil {
+		in, out := &in.Applications, &out.Applications
+		*out = make([]WildflyAppStatus, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -126,3 +844,60 @@ func (in *WildflyStatus) DeepCopy() *WildflyStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WildflyStorage) DeepCopyInto(out *WildflyStorage) {
+	*out = *in
+	if in.Data != nil {
+		in, out := &in.Data, &out.Data
+		*out = new(WildflyVolumeClaim)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Logs != nil {
+		in, out := &in.Logs, &out.Logs
+		*out = new(WildflyVolumeClaim)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FSGroup != nil {
+		in, out := &in.FSGroup, &out.FSGroup
+		*out = new(int64)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WildflyStorage.
+func (in *WildflyStorage) DeepCopy() *WildflyStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(WildflyStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WildflyVolumeClaim) DeepCopyInto(out *WildflyVolumeClaim) {
+	*out = *in
+	if in.StorageClassName != nil {
+		in, out := &in.StorageClassName, &out.StorageClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.AccessModes != nil {
+		in, out := &in.AccessModes, &out.AccessModes
+		*out = make([]v1.PersistentVolumeAccessMode, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WildflyVolumeClaim.
+func (in *WildflyVolumeClaim) DeepCopy() *WildflyVolumeClaim {
+	if in == nil {
+		return nil
+	}
+	out := new(WildflyVolumeClaim)
+	in.DeepCopyInto(out)
+	return out
+}
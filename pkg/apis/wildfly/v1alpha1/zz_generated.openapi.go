@@ -13,9 +13,15 @@ import (
 
 func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenAPIDefinition {
 	return map[string]common.OpenAPIDefinition{
-		"github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.Wildfly":       schema_pkg_apis_wildfly_v1alpha1_Wildfly(ref),
-		"github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflySpec":   schema_pkg_apis_wildfly_v1alpha1_WildflySpec(ref),
-		"github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyStatus": schema_pkg_apis_wildfly_v1alpha1_WildflyStatus(ref),
+		"github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.Wildfly":                  schema_pkg_apis_wildfly_v1alpha1_Wildfly(ref),
+		"github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyApplication":       schema_pkg_apis_wildfly_v1alpha1_WildflyApplication(ref),
+		"github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyApplicationSpec":   schema_pkg_apis_wildfly_v1alpha1_WildflyApplicationSpec(ref),
+		"github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyApplicationStatus": schema_pkg_apis_wildfly_v1alpha1_WildflyApplicationStatus(ref),
+		"github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyDatasource":        schema_pkg_apis_wildfly_v1alpha1_WildflyDatasource(ref),
+		"github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyDatasourceSpec":    schema_pkg_apis_wildfly_v1alpha1_WildflyDatasourceSpec(ref),
+		"github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyDatasourceStatus":  schema_pkg_apis_wildfly_v1alpha1_WildflyDatasourceStatus(ref),
+		"github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflySpec":              schema_pkg_apis_wildfly_v1alpha1_WildflySpec(ref),
+		"github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyStatus":            schema_pkg_apis_wildfly_v1alpha1_WildflyStatus(ref),
 	}
 }
 
@@ -62,15 +68,499 @@ func schema_pkg_apis_wildfly_v1alpha1_Wildfly(ref common.ReferenceCallback) comm
 	}
 }
 
+func schema_pkg_apis_wildfly_v1alpha1_WildflyApplication(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "WildflyApplication is the Schema for the wildflyapplications API",
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"metadata": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"),
+						},
+					},
+					"spec": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyApplicationSpec"),
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyApplicationStatus"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyApplicationSpec", "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyApplicationStatus", "k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"},
+	}
+}
+
+func schema_pkg_apis_wildfly_v1alpha1_WildflyApplicationSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "WildflyApplicationSpec defines the desired state of WildflyApplication. The artifact served at URL, checked against its SHA256 checksum when set, is deployed at runtime on the servers of the Wildfly named by Wildfly through their management interface. DeploymentName defaults to the file name of the URL. With RollbackOnFailure, an artifact failing to deploy is replaced by the previous one.",
+				Properties: map[string]spec.Schema{
+					"wildfly": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"url": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"sha256": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"version": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"deploymentName": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"rollbackOnFailure": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"boolean"},
+							Format: "",
+						},
+					},
+				},
+				Required: []string{"wildfly", "url"},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
+func schema_pkg_apis_wildfly_v1alpha1_WildflyApplicationStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "WildflyApplicationStatus defines the observed state of WildflyApplication",
+				Properties: map[string]spec.Schema{
+					"phase": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"message": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"current": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyArtifact"),
+						},
+					},
+					"previous": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyArtifact"),
+						},
+					},
+					"failed": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyArtifact"),
+						},
+					},
+					"servers": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyApplicationServer"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyApplicationServer", "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyArtifact"},
+	}
+}
+
+func schema_pkg_apis_wildfly_v1alpha1_WildflyDatasource(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "WildflyDatasource is the Schema for the wildflydatasources API",
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"metadata": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"),
+						},
+					},
+					"spec": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyDatasourceSpec"),
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyDatasourceStatus"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyDatasourceSpec", "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyDatasourceStatus", "k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"},
+	}
+}
+
+func schema_pkg_apis_wildfly_v1alpha1_WildflyDatasourceSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "WildflyDatasourceSpec defines the desired state of WildflyDatasource. The datasource is created at runtime through the management interface on the servers of each Wildfly named in Wildflies, which must be in the same namespace.",
+				Properties: map[string]spec.Schema{
+					"wildflies": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"name": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"jndiName": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"driver": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"connectionURL": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"minPoolSize": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int32",
+						},
+					},
+					"maxPoolSize": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int32",
+						},
+					},
+					"credentialsSecret": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+				},
+				Required: []string{"wildflies", "name", "jndiName", "driver", "connectionURL"},
+			},
+		},
+		Dependencies: []string{},
+	}
+}
+
+func schema_pkg_apis_wildfly_v1alpha1_WildflyDatasourceStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "WildflyDatasourceStatus defines the observed state of WildflyDatasource",
+				Properties: map[string]spec.Schema{
+					"servers": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyDatasourceServer"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyDatasourceServer"},
+	}
+}
+
 func schema_pkg_apis_wildfly_v1alpha1_WildflySpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
 	return common.OpenAPIDefinition{
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
 				Description: "WildflySpec defines the desired state of Wildfly",
-				Properties:  map[string]spec.Schema{},
+				Properties: map[string]spec.Schema{
+					"size": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int32",
+						},
+					},
+					"image": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"version": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"cmd": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"ports": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyPortProto"),
+									},
+								},
+							},
+						},
+					},
+					"nodePort": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"boolean"},
+							Format: "",
+						},
+					},
+					"config": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyConfig"),
+						},
+					},
+					"datasources": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyDatasourceDefinition"),
+									},
+								},
+							},
+						},
+					},
+					"management": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyManagement"),
+						},
+					},
+					"probes": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyProbes"),
+						},
+					},
+					"mode": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"storage": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyStorage"),
+						},
+					},
+					"suspendTimeoutSeconds": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int32",
+						},
+					},
+					"clustering": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyClustering"),
+						},
+					},
+					"expose": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyExpose"),
+						},
+					},
+					"https": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyHTTPS"),
+						},
+					},
+					"applications": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyApp"),
+									},
+								},
+							},
+						},
+					},
+					"drivers": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyDriver"),
+									},
+								},
+							},
+						},
+					},
+					"cliScripts": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"env": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("k8s.io/api/core/v1.EnvVar"),
+									},
+								},
+							},
+						},
+					},
+					"envFrom": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("k8s.io/api/core/v1.EnvFromSource"),
+									},
+								},
+							},
+						},
+					},
+					"systemProperties": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"object"},
+							AdditionalProperties: &spec.SchemaOrBool{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"resources": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("k8s.io/api/core/v1.ResourceRequirements"),
+						},
+					},
+					"jvm": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyJVM"),
+						},
+					},
+					"monitoring": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyMonitoring"),
+						},
+					},
+				},
+				Required: []string{"size", "image", "version", "cmd", "ports", "nodePort"},
 			},
 		},
-		Dependencies: []string{},
+		Dependencies: []string{
+			"github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyApp", "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyClustering", "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyConfig", "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyDatasourceDefinition", "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyDriver", "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyExpose", "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyHTTPS", "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyJVM", "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyManagement", "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyMonitoring", "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyPortProto", "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyProbes", "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyStorage", "k8s.io/api/core/v1.EnvFromSource", "k8s.io/api/core/v1.EnvVar", "k8s.io/api/core/v1.ResourceRequirements"},
 	}
 }
 
@@ -79,9 +569,126 @@ func schema_pkg_apis_wildfly_v1alpha1_WildflyStatus(ref common.ReferenceCallback
 		Schema: spec.Schema{
 			SchemaProps: spec.SchemaProps{
 				Description: "WildflyStatus defines the observed state of Wildfly",
-				Properties:  map[string]spec.Schema{},
+				Properties: map[string]spec.Schema{
+					"mode": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"replicas": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int32",
+						},
+					},
+					"readyReplicas": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"integer"},
+							Format: "int32",
+						},
+					},
+					"pods": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
+					"image": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"clusterIP": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"nodePorts": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyNodePort"),
+									},
+								},
+							},
+						},
+					},
+					"conditions": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyCondition"),
+									},
+								},
+							},
+						},
+					},
+					"servers": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyServer"),
+									},
+								},
+							},
+						},
+					},
+					"scaleDown": {
+						SchemaProps: spec.SchemaProps{
+							Ref: ref("github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyScaleDown"),
+						},
+					},
+					"recoveries": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyDrain"),
+									},
+								},
+							},
+						},
+					},
+					"host": {
+						SchemaProps: spec.SchemaProps{
+							Type:   []string{"string"},
+							Format: "",
+						},
+					},
+					"applications": {
+						SchemaProps: spec.SchemaProps{
+							Type: []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Ref: ref("github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyAppStatus"),
+									},
+								},
+							},
+						},
+					},
+				},
+				Required: []string{"replicas", "readyReplicas"},
 			},
 		},
-		Dependencies: []string{},
+		Dependencies: []string{
+			"github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyAppStatus", "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyCondition", "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyDrain", "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyNodePort", "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyScaleDown", "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1.WildflyServer"},
 	}
 }
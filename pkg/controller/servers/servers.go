@@ -0,0 +1,108 @@
+// Package servers gives the controllers access to the management interface of the servers
+// running in the pods of a Wildfly.
+package servers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+	"github.com/giannisalinetti/wildfly-operator/pkg/management"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ManagementEnabled reports whether the operator is allowed to talk to the management
+// interface of the servers
+func ManagementEnabled(wildfly *wildflyv1alpha1.Wildfly) bool {
+	return wildfly.Spec.Management != nil && wildfly.Spec.Management.CredentialsSecret != ""
+}
+
+// Credentials reads the management user from the Secret referenced by the Wildfly
+func Credentials(c client.Client, wildfly *wildflyv1alpha1.Wildfly) (string, string, error) {
+	secret := &corev1.Secret{}
+	err := c.Get(context.TODO(), types.NamespacedName{Name: wildfly.Spec.Management.CredentialsSecret, Namespace: wildfly.Namespace}, secret)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get management Secret %s: %v", wildfly.Spec.Management.CredentialsSecret, err)
+	}
+	username := string(secret.Data[corev1.BasicAuthUsernameKey])
+	password := string(secret.Data[corev1.BasicAuthPasswordKey])
+	if username == "" || password == "" {
+		return "", "", fmt.Errorf("management Secret %s must define both %s and %s", secret.Name,
+			corev1.BasicAuthUsernameKey, corev1.BasicAuthPasswordKey)
+	}
+	return username, password, nil
+}
+
+// NewClients returns a management client for each running pod among the given pods of the
+// Wildfly, keyed by pod name. Pods without an IP yet are skipped.
+func NewClients(c client.Client, wildfly *wildflyv1alpha1.Wildfly, pods []corev1.Pod) (map[string]*management.Client, error) {
+	username, password, err := Credentials(c, wildfly)
+	if err != nil {
+		return nil, err
+	}
+	clients := map[string]*management.Client{}
+	for _, pod := range pods {
+		if pod.Status.PodIP == "" || pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		clients[pod.Name] = management.NewClientForHost(pod.Status.PodIP, username, password)
+	}
+	return clients, nil
+}
+
+// ManagementClients returns a management client for each ready pod of the Wildfly, keyed by
+// pod name. The servers which are not ready yet are left out since the resources applied
+// through the management interface are applied again when they become ready. An error is
+// returned when the management interface is not enabled or no server is ready.
+func ManagementClients(c client.Client, wildfly *wildflyv1alpha1.Wildfly) (map[string]*management.Client, error) {
+	if !ManagementEnabled(wildfly) {
+		return nil, fmt.Errorf("the management interface of Wildfly %s is not enabled", wildfly.Name)
+	}
+
+	podList := &corev1.PodList{}
+	opts := client.InNamespace(wildfly.Namespace).MatchingLabels(map[string]string{"app": wildfly.Name})
+	err := c.List(context.TODO(), opts, podList)
+	if err != nil {
+		return nil, err
+	}
+	var ready []corev1.Pod
+	for _, pod := range podList.Items {
+		if PodReady(&pod) {
+			ready = append(ready, pod)
+		}
+	}
+	clients, err := NewClients(c, wildfly, ready)
+	if err != nil {
+		return nil, err
+	}
+	if len(clients) == 0 {
+		return nil, fmt.Errorf("no server of Wildfly %s is ready", wildfly.Name)
+	}
+	return clients, nil
+}
+
+// PodReady reports whether the pod is running and ready
+func PodReady(pod *corev1.Pod) bool {
+	if pod.Status.Phase != corev1.PodRunning {
+		return false
+	}
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// PodNames returns the pod names of the clients in order
+func PodNames(clients map[string]*management.Client) []string {
+	pods := []string{}
+	for pod := range clients {
+		pods = append(pods, pod)
+	}
+	sort.Strings(pods)
+	return pods
+}
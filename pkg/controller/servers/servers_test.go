@@ -0,0 +1,34 @@
+package servers
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestPodReady(t *testing.T) {
+	pod := func(phase corev1.PodPhase, ready corev1.ConditionStatus) *corev1.Pod {
+		p := &corev1.Pod{}
+		p.Status.Phase = phase
+		if ready != "" {
+			p.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}}
+		}
+		return p
+	}
+
+	tests := []struct {
+		name string
+		pod  *corev1.Pod
+		want bool
+	}{
+		{"running and ready", pod(corev1.PodRunning, corev1.ConditionTrue), true},
+		{"running and not ready", pod(corev1.PodRunning, corev1.ConditionFalse), false},
+		{"running without condition", pod(corev1.PodRunning, ""), false},
+		{"pending", pod(corev1.PodPending, corev1.ConditionTrue), false},
+	}
+	for _, tt := range tests {
+		if got := PodReady(tt.pod); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
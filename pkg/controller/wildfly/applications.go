@@ -0,0 +1,238 @@
+package wildfly
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+	"github.com/giannisalinetti/wildfly-operator/pkg/controller/servers"
+	"github.com/giannisalinetti/wildfly-operator/pkg/management"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	applicationsPath           = "/applications"
+	applicationsVolumeName     = volumePrefix + "applications"
+	applicationsHashAnnotation = annotationPrefix + "applications-hash"
+	downloadContainerName      = "wildfly-download"
+)
+
+// validateApplications checks that every application has a valid file name and exactly one
+// source
+func validateApplications(cr *wildflyv1alpha1.Wildfly) error {
+	names := map[string]bool{}
+	for _, app := range cr.Spec.Applications {
+		if app.Name == "" || strings.ContainsAny(app.Name, "/\\") || strings.HasPrefix(app.Name, ".") {
+			return fmt.Errorf("invalid application name %q", app.Name)
+		}
+		if names[app.Name] {
+			return fmt.Errorf("duplicate application %s", app.Name)
+		}
+		names[app.Name] = true
+
+		sources := 0
+		for _, set := range []bool{app.URL != "", app.ConfigMap != "", app.Secret != "", app.Image != ""} {
+			if set {
+				sources++
+			}
+		}
+		if sources != 1 {
+			return fmt.Errorf("application %s must have exactly one of url, configMap, secret or image", app.Name)
+		}
+		if app.Image != "" && app.Path == "" {
+			return fmt.Errorf("application %s must set the path of the artifact in the image", app.Name)
+		}
+	}
+	return nil
+}
+
+// applicationKey returns the key of the ConfigMap or Secret holding an application
+func applicationKey(app wildflyv1alpha1.WildflyApp) string {
+	if app.Key != "" {
+		return app.Key
+	}
+	return app.Name
+}
+
+// shellQuote quotes a value for the shell
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// downloadScript returns the shell script fetching the applications served over HTTP and
+// verifying their checksum
+func downloadScript(cr *wildflyv1alpha1.Wildfly) string {
+	lines := []string{"set -e"}
+	for _, app := range cr.Spec.Applications {
+		if app.URL == "" {
+			continue
+		}
+		target := shellQuote(applicationsPath + "/" + app.Name)
+		lines = append(lines, "echo "+shellQuote("Downloading "+app.URL),
+			"curl -fsSL -o "+target+" "+shellQuote(app.URL))
+		if app.SHA256 != "" {
+			lines = append(lines, "echo "+shellQuote(strings.ToLower(app.SHA256)+"  "+applicationsPath+"/"+app.Name)+" | sha256sum -c -")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// addApplications copies the applications into standalone/deployments, where the deployment
+// scanner deploys them. Each artifact is mounted on its own so that the applications shipped
+// with the image stay in place. The artifacts fetched over HTTP or copied from an image are
+// stored in a volume filled by init containers, the others are mounted from their ConfigMap or
+// Secret. The hash of the sources is stamped on the pod template so that any change triggers
+// a rolling restart of the pods.
+func (r *ReconcileWildfly) addApplications(cr *wildflyv1alpha1.Wildfly, template *corev1.PodTemplateSpec) error {
+	if len(cr.Spec.Applications) == 0 {
+		return nil
+	}
+	err := validateApplications(cr)
+	if err != nil {
+		return err
+	}
+
+	h := sha256.New()
+	container := &template.Spec.Containers[0]
+	applicationsMount := corev1.VolumeMount{
+		Name:      applicationsVolumeName,
+		MountPath: applicationsPath,
+	}
+	downloads := false
+	for i, app := range cr.Spec.Applications {
+		mount := corev1.VolumeMount{
+			Name:      applicationsVolumeName,
+			MountPath: deploymentsPath + "/" + app.Name,
+			SubPath:   app.Name,
+			ReadOnly:  true,
+		}
+		switch {
+		case app.URL != "":
+			downloads = true
+			h.Write([]byte(app.URL + "\x00" + app.SHA256))
+		case app.Image != "":
+			template.Spec.InitContainers = append(template.Spec.InitContainers, corev1.Container{
+				Name:         volumePrefix + "app-" + strconv.Itoa(i),
+				Image:        app.Image,
+				Command:      []string{"cp", app.Path, applicationsPath + "/" + app.Name},
+				VolumeMounts: []corev1.VolumeMount{applicationsMount},
+			})
+		case app.ConfigMap != "":
+			cm := &corev1.ConfigMap{}
+			err := r.client.Get(context.TODO(), types.NamespacedName{Name: app.ConfigMap, Namespace: cr.Namespace}, cm)
+			if err != nil {
+				return fmt.Errorf("failed to get ConfigMap %s of application %s: %v", app.ConfigMap, app.Name, err)
+			}
+			content, ok := cm.BinaryData[applicationKey(app)]
+			if !ok {
+				content = []byte(cm.Data[applicationKey(app)])
+			}
+			h.Write(content)
+			mount.Name = volumePrefix + "app-" + strconv.Itoa(i)
+			mount.SubPath = applicationKey(app)
+			addConfigMapVolume(template, mount.Name, app.ConfigMap)
+		case app.Secret != "":
+			secret := &corev1.Secret{}
+			err := r.client.Get(context.TODO(), types.NamespacedName{Name: app.Secret, Namespace: cr.Namespace}, secret)
+			if err != nil {
+				return fmt.Errorf("failed to get Secret %s of application %s: %v", app.Secret, app.Name, err)
+			}
+			h.Write(secret.Data[applicationKey(app)])
+			mount.Name = volumePrefix + "app-" + strconv.Itoa(i)
+			mount.SubPath = applicationKey(app)
+			mode := configMapDefaultMode
+			template.Spec.Volumes = append(template.Spec.Volumes, corev1.Volume{
+				Name: mount.Name,
+				VolumeSource: corev1.VolumeSource{
+					Secret: &corev1.SecretVolumeSource{
+						SecretName:  app.Secret,
+						DefaultMode: &mode,
+					},
+				},
+			})
+		}
+		h.Write([]byte{0})
+		container.VolumeMounts = append(container.VolumeMounts, mount)
+	}
+
+	if downloads {
+		template.Spec.InitContainers = append(template.Spec.InitContainers, corev1.Container{
+			Name:         downloadContainerName,
+			Image:        container.Image,
+			Command:      []string{"/bin/sh", "-c", downloadScript(cr)},
+			VolumeMounts: []corev1.VolumeMount{applicationsMount},
+		})
+	}
+	template.Spec.Volumes = append(template.Spec.Volumes, corev1.Volume{
+		Name: applicationsVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	})
+
+	setPodAnnotation(template, applicationsHashAnnotation, hex.EncodeToString(h.Sum(nil)))
+	return nil
+}
+
+// readApplications returns the deployment status of each application, aggregated across the
+// servers through the management interface
+func (r *ReconcileWildfly) readApplications(cr *wildflyv1alpha1.Wildfly, pods []corev1.Pod) []wildflyv1alpha1.WildflyAppStatus {
+	var statuses []wildflyv1alpha1.WildflyAppStatus
+	if len(cr.Spec.Applications) == 0 {
+		return statuses
+	}
+	if !managementEnabled(cr) {
+		for _, app := range cr.Spec.Applications {
+			statuses = append(statuses, wildflyv1alpha1.WildflyAppStatus{
+				Name:    app.Name,
+				Status:  wildflyv1alpha1.AppUnknown,
+				Message: "the management interface is not enabled",
+			})
+		}
+		return statuses
+	}
+
+	clients, err := servers.NewClients(r.client, cr, pods)
+	if err != nil {
+		log.Printf("Failed to create management clients: %v\n", err)
+		clients = map[string]*management.Client{}
+	}
+	for _, app := range cr.Spec.Applications {
+		status := wildflyv1alpha1.WildflyAppStatus{Name: app.Name, Status: wildflyv1alpha1.AppDeployed}
+		if len(clients) == 0 {
+			status.Status = wildflyv1alpha1.AppPending
+			status.Message = "no server is running"
+		}
+		for _, pod := range pods {
+			c, ok := clients[pod.Name]
+			if !ok {
+				continue
+			}
+			deployment, err := c.DeploymentStatus(app.Name)
+			switch {
+			case management.IsNotFound(err):
+				deployment = "not deployed yet"
+			case err != nil:
+				deployment = err.Error()
+			}
+			if deployment == management.DeploymentStatusOK {
+				continue
+			}
+			if deployment == management.DeploymentStatusFailed {
+				status.Status = wildflyv1alpha1.AppFailed
+				status.Message = "deployment failed on pod " + pod.Name
+				break
+			}
+			status.Status = wildflyv1alpha1.AppPending
+			status.Message = "pod " + pod.Name + ": " + deployment
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
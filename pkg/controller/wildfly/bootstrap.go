@@ -0,0 +1,268 @@
+package wildfly
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	bootstrapContainerName     = "wildfly-bootstrap"
+	bootstrapPath              = "/bootstrap"
+	bootstrapScriptsPath       = bootstrapPath + "/scripts"
+	bootstrapOverlayPath       = bootstrapPath + "/overlay"
+	bootstrapConfigurationPath = bootstrapPath + "/configuration"
+	bootstrapCliPath           = bootstrapPath + "/cli"
+	bootstrapVolumeName        = volumePrefix + "bootstrap"
+	configurationVolumeName    = volumePrefix + "configuration"
+	bootstrapHashAnnotation    = annotationPrefix + "bootstrap-hash"
+	bootstrapScriptKey         = "bootstrap.sh"
+	serverConfigDefault        = "standalone.xml"
+	serverConfigHA             = "standalone-ha.xml"
+	serverConfigEnvVar         = "WILDFLY_SERVER_CONFIG"
+	// bootstrapFailedReason is the reason of the Degraded condition set when the bootstrap
+	// container of a pod fails
+	bootstrapFailedReason = "BootstrapFailed"
+)
+
+// bootstrapScript prepares the configuration directory of the server before it starts: the
+// files of the image are copied to a volume shared with the wildfly container, overridden by
+// the files of the ConfigMap referenced by Spec.Config, the management user is added to
+// mgmt-users.properties, then the CLI scripts generated by the operator and the ones of
+// Spec.CliScripts are applied in embedded-server mode in lexical
+// order. The scripts of Spec.CliScripts are wrapped in an embedded server unless they start
+// one themselves. The output of a failed script is written to the termination log of the
+// container.
+const bootstrapScript = `#!/bin/sh
+set -e
+JBOSS_HOME=${JBOSS_HOME:-/opt/jboss/wildfly}
+TARGET=` + bootstrapConfigurationPath + `
+
+run_cli() {
+    echo "Running $2"
+    if ! JAVA_OPTS="$JAVA_OPTS -Djboss.server.config.dir=$TARGET" "$JBOSS_HOME/bin/jboss-cli.sh" --file="$1" > /tmp/bootstrap.out 2>&1; then
+        cat /tmp/bootstrap.out
+        { echo "$2 failed:"; tail -c 3968 /tmp/bootstrap.out; } > /dev/termination-log
+        exit 1
+    fi
+    cat /tmp/bootstrap.out
+}
+
+cp -a "$JBOSS_HOME/standalone/configuration/." "$TARGET/"
+if [ -d ` + bootstrapOverlayPath + ` ]; then
+    for f in ` + bootstrapOverlayPath + `/*; do
+        [ -e "$f" ] && cp -L "$f" "$TARGET/"
+    done
+fi
+
+if [ -f ` + managementUserPath + `/` + managementUsersKey + ` ]; then
+    entry=$(cat ` + managementUserPath + `/` + managementUsersKey + `)
+    touch "$TARGET/` + managementUsersKey + `"
+    awk -F= -v user="${entry%%=*}" '$1 != user' "$TARGET/` + managementUsersKey + `" > /tmp/users.properties
+    echo "$entry" >> /tmp/users.properties
+    cp /tmp/users.properties "$TARGET/` + managementUsersKey + `"
+fi
+
+for script in ` + bootstrapScriptsPath + `/*.cli; do
+    [ -e "$script" ] || continue
+    run_cli "$script" "$script"
+done
+
+for script in ` + bootstrapCliPath + `/*/*.cli; do
+    [ -e "$script" ] || continue
+    if grep -q '^[[:space:]]*embed-server' "$script"; then
+        run_cli "$script" "$script"
+    else
+        { echo "embed-server --server-config=$` + serverConfigEnvVar + ` --std-out=discard"; cat "$script"; echo; echo "stop-embedded-server"; } > /tmp/embedded.cli
+        run_cli /tmp/embedded.cli "$script"
+    fi
+done
+`
+
+// serverConfig returns the configuration file the server is started with
+func serverConfig(cr *wildflyv1alpha1.Wildfly) string {
+	if clusteringEnabled(cr) {
+		return serverConfigHA
+	}
+	return serverConfigDefault
+}
+
+// embeddedScript wraps CLI commands so that they are run against the configuration of an
+// embedded server, the server is not started yet when the bootstrap container runs
+func embeddedScript(cr *wildflyv1alpha1.Wildfly, commands ...string) string {
+	lines := []string{"embed-server --server-config=" + serverConfig(cr) + " --std-out=discard"}
+	lines = append(lines, commands...)
+	lines = append(lines, "stop-embedded-server", "")
+	return strings.Join(lines, "\n")
+}
+
+// bootstrapScripts returns the CLI scripts generated for the Wildfly, by file name
+func bootstrapScripts(cr *wildflyv1alpha1.Wildfly) map[string]string {
+	scripts := map[string]string{}
+	if len(cr.Spec.Drivers) > 0 && validateDrivers(cr) == nil {
+		scripts["05-drivers.cli"] = driversScript(cr)
+	}
+	if clusteringEnabled(cr) {
+		scripts["10-clustering.cli"] = clusteringScript(cr)
+	}
+	if httpsEnabled(cr) {
+		scripts["20-https.cli"] = httpsScript(cr)
+	}
+	if monitoringEnabled(cr) {
+		scripts["30-monitoring.cli"] = monitoringScript(cr)
+	}
+	return scripts
+}
+
+// bootstrapNeeded reports whether the configuration directory must be prepared before the
+// server starts
+func bootstrapNeeded(cr *wildflyv1alpha1.Wildfly) bool {
+	return (cr.Spec.Config != nil && cr.Spec.Config.ConfigMap != "") || len(cr.Spec.CliScripts) > 0 ||
+		managementEnabled(cr) || len(bootstrapScripts(cr)) > 0
+}
+
+// bootstrapConfigMapName returns the name of the ConfigMap holding the bootstrap scripts
+func bootstrapConfigMapName(cr *wildflyv1alpha1.Wildfly) string {
+	return cr.Name + "-bootstrap"
+}
+
+// bootstrapData returns the content of the bootstrap ConfigMap, nil when it is not needed
+func bootstrapData(cr *wildflyv1alpha1.Wildfly) map[string]string {
+	if !bootstrapNeeded(cr) {
+		return nil
+	}
+	data := bootstrapScripts(cr)
+	data[bootstrapScriptKey] = bootstrapScript
+	return data
+}
+
+// reconcileBootstrap creates or updates the ConfigMap holding the bootstrap scripts of the
+// Wildfly, and removes it when the configuration does not need to be prepared anymore.
+func (r *ReconcileWildfly) reconcileBootstrap(cr *wildflyv1alpha1.Wildfly) error {
+	return r.reconcileGeneratedConfigMap(cr, bootstrapConfigMapName(cr), bootstrapData(cr))
+}
+
+// addBootstrap adds the init container preparing standalone/configuration in a volume which
+// is then mounted by the wildfly container in place of the directory of the image. The hash
+// of the scripts is stamped on the pod template to restart the pods when they change.
+func addBootstrap(cr *wildflyv1alpha1.Wildfly, template *corev1.PodTemplateSpec) {
+	data := bootstrapData(cr)
+	if data == nil {
+		return
+	}
+
+	addConfigMapVolume(template, bootstrapVolumeName, bootstrapConfigMapName(cr))
+	template.Spec.Volumes = append(template.Spec.Volumes, corev1.Volume{
+		Name: configurationVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	})
+
+	container := &template.Spec.Containers[0]
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      configurationVolumeName,
+		MountPath: configurationPath,
+	})
+	template.Spec.InitContainers = append(template.Spec.InitContainers, corev1.Container{
+		Name:    bootstrapContainerName,
+		Image:   container.Image,
+		Command: []string{"/bin/sh", bootstrapScriptsPath + "/" + bootstrapScriptKey},
+		Env: []corev1.EnvVar{{
+			Name:  serverConfigEnvVar,
+			Value: serverConfig(cr),
+		}},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      bootstrapVolumeName,
+				MountPath: bootstrapScriptsPath,
+				ReadOnly:  true,
+			},
+			{
+				Name:      configurationVolumeName,
+				MountPath: bootstrapConfigurationPath,
+			},
+		},
+	})
+
+	setPodAnnotation(template, bootstrapHashAnnotation, dataHash(data))
+}
+
+// bootstrapFailure reports whether the bootstrap container of a pod failed, with the output of
+// the failed script written to its termination log as the message
+func bootstrapFailure(pods []corev1.Pod) (string, bool) {
+	for _, pod := range pods {
+		for _, cs := range pod.Status.InitContainerStatuses {
+			if cs.Name != bootstrapContainerName {
+				continue
+			}
+			// A container waiting to be restarted reports its failure in the last state
+			var terminated *corev1.ContainerStateTerminated
+			switch {
+			case cs.State.Terminated != nil:
+				terminated = cs.State.Terminated
+			case cs.State.Waiting != nil:
+				terminated = cs.LastTerminationState.Terminated
+			}
+			if terminated == nil || terminated.ExitCode == 0 {
+				continue
+			}
+			return "pod " + pod.Name + ": " + terminated.Message, true
+		}
+	}
+	return "", false
+}
+
+// addBootstrapMount mounts a volume in the bootstrap container, which must have been added
+func addBootstrapMount(template *corev1.PodTemplateSpec, mount corev1.VolumeMount) {
+	if c := findContainer(template.Spec.InitContainers, bootstrapContainerName); c != nil {
+		c.VolumeMounts = append(c.VolumeMounts, mount)
+	}
+}
+
+// dataHash returns a stable sha256 hash of the data of a ConfigMap
+func dataHash(data map[string]string) string {
+	keys := []string{}
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, k := range keys {
+		h.Write([]byte(k))
+		h.Write([]byte{0})
+		h.Write([]byte(data[k]))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// mergeOwnedInitContainers returns the found init containers with the ones owned by the
+// operator replaced by the desired set, ahead of the others. The fields not set by the
+// operator are kept from the found containers, since the API server defaults them.
+func mergeOwnedInitContainers(found, desired []corev1.Container) []corev1.Container {
+	var merged []corev1.Container
+	for _, d := range desired {
+		c := d
+		if f := findContainer(found, d.Name); f != nil {
+			c = *f.DeepCopy()
+			c.Image = d.Image
+			c.Command = d.Command
+			c.Args = d.Args
+			c.Env = d.Env
+			c.VolumeMounts = d.VolumeMounts
+			c.Resources = d.Resources
+		}
+		merged = append(merged, c)
+	}
+	for _, f := range found {
+		if !strings.HasPrefix(f.Name, volumePrefix) {
+			merged = append(merged, f)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,45 @@
+package wildfly
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const cliScriptsHashAnnotation = annotationPrefix + "cli-scripts-hash"
+
+// addCliScripts mounts the ConfigMaps of Spec.CliScripts in the bootstrap container, which
+// runs their *.cli keys after the scripts generated by the operator. Each ConfigMap gets a
+// zero-padded directory so that they run in the order of the spec. The hash of the scripts is
+// stamped on the pod template to restart the pods when they change.
+func (r *ReconcileWildfly) addCliScripts(cr *wildflyv1alpha1.Wildfly, template *corev1.PodTemplateSpec) error {
+	if len(cr.Spec.CliScripts) == 0 {
+		return nil
+	}
+
+	h := sha256.New()
+	for i, name := range cr.Spec.CliScripts {
+		cm := &corev1.ConfigMap{}
+		err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: cr.Namespace}, cm)
+		if err != nil {
+			return fmt.Errorf("failed to get CLI scripts ConfigMap %s referenced by Wildfly %s: %v", name, cr.Name, err)
+		}
+		h.Write([]byte(configMapHash(cm)))
+
+		volumeName := fmt.Sprintf("%scli-%d", volumePrefix, i)
+		addConfigMapVolume(template, volumeName, name)
+		addBootstrapMount(template, corev1.VolumeMount{
+			Name:      volumeName,
+			MountPath: fmt.Sprintf("%s/%03d", bootstrapCliPath, i),
+			ReadOnly:  true,
+		})
+	}
+
+	setPodAnnotation(template, cliScriptsHashAnnotation, hex.EncodeToString(h.Sum(nil)))
+	return nil
+}
@@ -0,0 +1,199 @@
+package wildfly
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	clusterDomainDefault = "cluster.local"
+	podIPEnvVar          = "POD_IP"
+	clusteringSuffix     = "-jgroups"
+)
+
+// clusteringEnabled reports whether the servers form a JGroups cluster
+func clusteringEnabled(cr *wildflyv1alpha1.Wildfly) bool {
+	return cr.Spec.Clustering != nil
+}
+
+// discovery returns the JGroups discovery protocol of the cluster, DNS_PING by default
+func discovery(cr *wildflyv1alpha1.Wildfly) wildflyv1alpha1.WildflyDiscovery {
+	if cr.Spec.Clustering == nil || cr.Spec.Clustering.Discovery == "" {
+		return wildflyv1alpha1.DiscoveryDNSPing
+	}
+	return cr.Spec.Clustering.Discovery
+}
+
+// dnsPingEnabled reports whether the members are discovered through the headless Service
+func dnsPingEnabled(cr *wildflyv1alpha1.Wildfly) bool {
+	return clusteringEnabled(cr) && discovery(cr) == wildflyv1alpha1.DiscoveryDNSPing
+}
+
+// kubePingEnabled reports whether the members are discovered through the Kubernetes API
+func kubePingEnabled(cr *wildflyv1alpha1.Wildfly) bool {
+	return clusteringEnabled(cr) && discovery(cr) == wildflyv1alpha1.DiscoveryKubePing
+}
+
+// clusteringScript returns the CLI script switching the ee channel of the HA profile to the
+// TCP stack, since multicast is not available on most Kubernetes networks, and replacing
+// the MPING discovery of the stack with DNS_PING or KUBE_PING.
+func clusteringScript(cr *wildflyv1alpha1.Wildfly) string {
+	var ping string
+	switch discovery(cr) {
+	case wildflyv1alpha1.DiscoveryKubePing:
+		ping = fmt.Sprintf(`/subsystem=jgroups/stack=tcp/protocol=kubernetes.KUBE_PING:add(add-index=0, properties={namespace="%s", labels="app=%s"})`,
+			cr.Namespace, cr.Name)
+	default:
+		domain := cr.Spec.Clustering.ClusterDomain
+		if domain == "" {
+			domain = clusterDomainDefault
+		}
+		ping = fmt.Sprintf(`/subsystem=jgroups/stack=tcp/protocol=dns.DNS_PING:add(add-index=0, properties={dns_query="%s.%s.svc.%s", dns_record_type="A"})`,
+			headlessServiceName(cr), cr.Namespace, domain)
+	}
+	return embeddedScript(cr,
+		"batch",
+		"/subsystem=jgroups/channel=ee:write-attribute(name=stack, value=tcp)",
+		"/subsystem=jgroups/stack=tcp/protocol=MPING:remove",
+		ping,
+		"run-batch",
+	)
+}
+
+// addClustering binds the private interface used by JGroups to the address of the pod, and
+// runs the pods with the service account allowed to list the members when KUBE_PING is used.
+func addClustering(cr *wildflyv1alpha1.Wildfly, template *corev1.PodTemplateSpec) {
+	if !clusteringEnabled(cr) {
+		return
+	}
+	container := &template.Spec.Containers[0]
+	container.Env = append(container.Env, corev1.EnvVar{
+		Name: podIPEnvVar,
+		ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{
+				APIVersion: "v1",
+				FieldPath:  "status.podIP",
+			},
+		},
+	})
+	if kubePingEnabled(cr) {
+		template.Spec.ServiceAccountName = clusteringServiceAccountName(cr)
+	}
+}
+
+// clusteringServiceAccountName returns the name of the service account and of the RBAC
+// objects allowing the members to list each other with KUBE_PING
+func clusteringServiceAccountName(cr *wildflyv1alpha1.Wildfly) string {
+	return cr.Name + clusteringSuffix
+}
+
+// mergeServiceAccount sets the service account of the found pod template when the operator
+// owns it, and resets it when the operator does not need it anymore. A service account set
+// by another actor is left as it is.
+func mergeServiceAccount(cr *wildflyv1alpha1.Wildfly, found, desired *corev1.PodTemplateSpec) bool {
+	name := desired.Spec.ServiceAccountName
+	owned := clusteringServiceAccountName(cr)
+	if name == "" && found.Spec.ServiceAccountName != owned {
+		return false
+	}
+	if found.Spec.ServiceAccountName == name {
+		return false
+	}
+	found.Spec.ServiceAccountName = name
+	found.Spec.DeprecatedServiceAccount = name
+	return true
+}
+
+// reconcileClustering creates the service account, Role and RoleBinding needed by KUBE_PING,
+// and removes them when the members are not discovered through the Kubernetes API anymore.
+func (r *ReconcileWildfly) reconcileClustering(cr *wildflyv1alpha1.Wildfly) error {
+	name := clusteringServiceAccountName(cr)
+	labels := map[string]string{
+		"app": cr.Name,
+	}
+	objectMeta := metav1.ObjectMeta{
+		Name:      name,
+		Namespace: cr.Namespace,
+		Labels:    labels,
+	}
+	sa := &corev1.ServiceAccount{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ServiceAccount"},
+		ObjectMeta: objectMeta,
+	}
+	role := &rbacv1.Role{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "Role"},
+		ObjectMeta: objectMeta,
+		Rules: []rbacv1.PolicyRule{{
+			APIGroups: []string{""},
+			Resources: []string{"pods"},
+			Verbs:     []string{"get", "list"},
+		}},
+	}
+	binding := &rbacv1.RoleBinding{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "RoleBinding"},
+		ObjectMeta: objectMeta,
+		Subjects: []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      name,
+			Namespace: cr.Namespace,
+		}},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     name,
+		},
+	}
+
+	for _, obj := range []runtime.Object{sa, role, binding} {
+		desired := obj.(metav1.Object)
+		found := obj.DeepCopyObject()
+		err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: cr.Namespace}, found)
+		if err != nil && !errors.IsNotFound(err) {
+			log.Printf("Failed to get %T %s: %v\n", obj, name, err)
+			return err
+		}
+		exists := err == nil
+
+		if !kubePingEnabled(cr) {
+			if exists && metav1.IsControlledBy(found.(metav1.Object), cr) {
+				log.Printf("Deleting clustering %T: %s/%s\n", obj, cr.Namespace, name)
+				err = r.client.Delete(context.TODO(), found)
+				if err != nil && !errors.IsNotFound(err) {
+					return err
+				}
+			}
+			continue
+		}
+		if exists {
+			// The rules of the Role are the only field which can drift
+			if f, ok := found.(*rbacv1.Role); ok && !reflect.DeepEqual(f.Rules, role.Rules) {
+				f.Rules = role.Rules
+				log.Printf("Updating clustering Role: %s/%s\n", cr.Namespace, name)
+				err = r.client.Update(context.TODO(), f)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		controllerutil.SetControllerReference(cr, desired, r.scheme)
+		log.Printf("Creating clustering %T: %s/%s\n", obj, cr.Namespace, name)
+		err = r.client.Create(context.TODO(), obj)
+		if err != nil {
+			log.Printf("Failed to create %T %s: %v\n", obj, name, err)
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package wildfly
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	configurationPath    = "/opt/jboss/wildfly/standalone/configuration"
+	configVolumeName     = volumePrefix + "config"
+	configHashAnnotation = annotationPrefix + "config-hash"
+	configMapDefaultMode = int32(0644)
+)
+
+// addConfigMap copies every key of the ConfigMap referenced by Spec.Config as a file into
+// standalone/configuration through the bootstrap container, and stamps the hash of its content
+// on the pod template so that any edit to the ConfigMap triggers a rolling restart of the pods.
+// The files shipped with the image that are not overridden by the ConfigMap stay available.
+func (r *ReconcileWildfly) addConfigMap(cr *wildflyv1alpha1.Wildfly, template *corev1.PodTemplateSpec) error {
+	if cr.Spec.Config == nil || cr.Spec.Config.ConfigMap == "" {
+		return nil
+	}
+
+	cm := &corev1.ConfigMap{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: cr.Spec.Config.ConfigMap, Namespace: cr.Namespace}, cm)
+	if err != nil {
+		return fmt.Errorf("failed to get ConfigMap %s referenced by Wildfly %s: %v", cr.Spec.Config.ConfigMap, cr.Name, err)
+	}
+
+	addConfigMapVolume(template, configVolumeName, cm.Name)
+	addBootstrapMount(template, corev1.VolumeMount{
+		Name:      configVolumeName,
+		MountPath: bootstrapOverlayPath,
+		ReadOnly:  true,
+	})
+
+	setPodAnnotation(template, configHashAnnotation, configMapHash(cm))
+	return nil
+}
+
+// addConfigMapVolume adds a volume backed by a ConfigMap to the pod template. The default mode
+// is set explicitly to the value defaulted by the API server to avoid spurious updates.
+func addConfigMapVolume(template *corev1.PodTemplateSpec, volumeName, configMapName string) {
+	mode := configMapDefaultMode
+	template.Spec.Volumes = append(template.Spec.Volumes, corev1.Volume{
+		Name: volumeName,
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: configMapName},
+				DefaultMode:          &mode,
+			},
+		},
+	})
+}
+
+// setPodAnnotation sets an annotation on the pod template
+func setPodAnnotation(template *corev1.PodTemplateSpec, key, value string) {
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	template.Annotations[key] = value
+}
+
+// configMapKeys returns the sorted keys of both the text and the binary data of a ConfigMap
+func configMapKeys(cm *corev1.ConfigMap) []string {
+	keys := []string{}
+	for k := range cm.Data {
+		keys = append(keys, k)
+	}
+	for k := range cm.BinaryData {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// configMapHash returns a stable sha256 hash of the content of a ConfigMap
+func configMapHash(cm *corev1.ConfigMap) string {
+	h := sha256.New()
+	for _, k := range configMapKeys(cm) {
+		h.Write([]byte(k))
+		h.Write([]byte{0})
+		if v, ok := cm.Data[k]; ok {
+			h.Write([]byte(v))
+		} else {
+			h.Write(cm.BinaryData[k])
+		}
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// newGeneratedConfigMap returns a ConfigMap owned by the Wildfly holding content generated by
+// the operator
+func (r *ReconcileWildfly) newGeneratedConfigMap(cr *wildflyv1alpha1.Wildfly, name string, data map[string]string) *corev1.ConfigMap {
+	cm := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: cr.Namespace,
+			Labels: map[string]string{
+				"app": cr.Name,
+			},
+		},
+		Data: data,
+	}
+	controllerutil.SetControllerReference(cr, cm, r.scheme)
+	return cm
+}
+
+// reconcileGeneratedConfigMap creates or updates a ConfigMap generated by the operator with the
+// given data, and removes it when data is nil.
+func (r *ReconcileWildfly) reconcileGeneratedConfigMap(cr *wildflyv1alpha1.Wildfly, name string, data map[string]string) error {
+	found := &corev1.ConfigMap{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: cr.Namespace}, found)
+	if err != nil && !errors.IsNotFound(err) {
+		log.Printf("Failed to get ConfigMap %s: %v\n", name, err)
+		return err
+	}
+	exists := err == nil
+
+	if data == nil {
+		if exists && metav1.IsControlledBy(found, cr) {
+			log.Printf("Deleting generated ConfigMap: %s/%s\n", found.Namespace, found.Name)
+			return r.client.Delete(context.TODO(), found)
+		}
+		return nil
+	}
+
+	if !exists {
+		desired := r.newGeneratedConfigMap(cr, name, data)
+		log.Printf("Creating generated ConfigMap: %s/%s\n", desired.Namespace, desired.Name)
+		return r.client.Create(context.TODO(), desired)
+	}
+	if !reflect.DeepEqual(found.Data, data) {
+		found.Data = data
+		log.Printf("Updating generated ConfigMap: %s/%s\n", found.Namespace, found.Name)
+		return r.client.Update(context.TODO(), found)
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package wildfly
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"text/template"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	deploymentsPath           = "/opt/jboss/wildfly/standalone/deployments"
+	datasourcesVolumeName     = volumePrefix + "datasources"
+	datasourcesHashAnnotation = annotationPrefix + "datasources-hash"
+	// The deployment scanner deploys any *-ds.xml file found in standalone/deployments
+	datasourcesKey = "wildfly-operator-ds.xml"
+)
+
+// datasourcesTemplate renders the datasources as a -ds.xml descriptor. Credentials are never
+// inlined, they are resolved by the server from the environment variables of the container.
+var datasourcesTemplate = template.Must(template.New("datasources").Funcs(template.FuncMap{
+	"xml": escapeXML,
+	"env": datasourceEnvPrefix,
+}).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<datasources xmlns="http://www.jboss.org/ironjacamar/schema">
+{{- range . }}
+    <datasource jndi-name="{{ xml .JNDIName }}" pool-name="{{ xml .Name }}" enabled="true" use-java-context="true">
+        <connection-url>{{ xml .ConnectionURL }}</connection-url>
+        <driver>{{ xml .Driver }}</driver>
+        {{- if or .MinPoolSize .MaxPoolSize }}
+        <pool>
+            {{- if .MinPoolSize }}
+            <min-pool-size>{{ .MinPoolSize }}</min-pool-size>
+            {{- end }}
+            {{- if .MaxPoolSize }}
+            <max-pool-size>{{ .MaxPoolSize }}</max-pool-size>
+            {{- end }}
+        </pool>
+        {{- end }}
+        {{- if .CredentialsSecret }}
+        <security>
+            <user-name>${env.{{ env .Name }}_USERNAME}</user-name>
+            <password>${env.{{ env .Name }}_PASSWORD}</password>
+        </security>
+        {{- end }}
+    </datasource>
+{{- end }}
+</datasources>
+`))
+
+var envNameInvalidChars = regexp.MustCompile(`[^A-Z0-9_]`)
+
+// datasourceEnvPrefix returns the prefix of the environment variables holding the
+// credentials of a datasource, e.g. DS_EXAMPLE_DB for a datasource named example-db.
+func datasourceEnvPrefix(name string) string {
+	return "DS_" + envNameInvalidChars.ReplaceAllString(strings.ToUpper(name), "_")
+}
+
+// escapeXML escapes a value to be placed in XML text or attributes
+func escapeXML(s string) (string, error) {
+	var b bytes.Buffer
+	err := xml.EscapeText(&b, []byte(s))
+	return b.String(), err
+}
+
+// validateDatasources checks the bounds of the pools, and that the datasources with
+// credentials do not share the prefix of their environment variables, e.g. my-ds and my_ds,
+// which would give them the same credentials
+func validateDatasources(cr *wildflyv1alpha1.Wildfly) error {
+	prefixes := map[string]string{}
+	for _, ds := range cr.Spec.Datasources {
+		if ds.MaxPoolSize > 0 && ds.MinPoolSize > ds.MaxPoolSize {
+			return fmt.Errorf("datasource %s has a minPoolSize %d greater than its maxPoolSize %d",
+				ds.Name, ds.MinPoolSize, ds.MaxPoolSize)
+		}
+		if ds.CredentialsSecret == "" {
+			continue
+		}
+		prefix := datasourceEnvPrefix(ds.Name)
+		if other, ok := prefixes[prefix]; ok {
+			return fmt.Errorf("datasources %s and %s have the same environment variables %s_*, rename one of them",
+				other, ds.Name, prefix)
+		}
+		prefixes[prefix] = ds.Name
+	}
+	return nil
+}
+
+// renderDatasources executes the datasources template for the datasources in the spec
+func renderDatasources(cr *wildflyv1alpha1.Wildfly) (string, error) {
+	err := validateDatasources(cr)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	err = datasourcesTemplate.Execute(&b, cr.Spec.Datasources)
+	if err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// datasourcesConfigMapName returns the name of the ConfigMap generated for the datasources
+func datasourcesConfigMapName(cr *wildflyv1alpha1.Wildfly) string {
+	return cr.Name + "-datasources"
+}
+
+// reconcileDatasources creates or updates the ConfigMap generated for the datasources of the
+// Wildfly, and removes it when no datasource is defined anymore.
+func (r *ReconcileWildfly) reconcileDatasources(cr *wildflyv1alpha1.Wildfly) error {
+	var data map[string]string
+	if len(cr.Spec.Datasources) > 0 {
+		content, err := renderDatasources(cr)
+		if err != nil {
+			log.Printf("Failed to render datasources: %v\n", err)
+			return err
+		}
+		data = map[string]string{datasourcesKey: content}
+	}
+	return r.reconcileGeneratedConfigMap(cr, datasourcesConfigMapName(cr), data)
+}
+
+// addDatasources mounts the generated datasources descriptor in standalone/deployments and
+// passes the credentials of each datasource from its Secret as environment variables. The
+// hash of the descriptor and of the credentials is stamped on the pod template, since the
+// kubelet only reads the variables when a container starts.
+func (r *ReconcileWildfly) addDatasources(cr *wildflyv1alpha1.Wildfly, template *corev1.PodTemplateSpec) error {
+	if len(cr.Spec.Datasources) == 0 {
+		return nil
+	}
+	content, err := renderDatasources(cr)
+	if err != nil {
+		return err
+	}
+
+	addConfigMapVolume(template, datasourcesVolumeName, datasourcesConfigMapName(cr))
+	container := &template.Spec.Containers[0]
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      datasourcesVolumeName,
+		MountPath: deploymentsPath + "/" + datasourcesKey,
+		SubPath:   datasourcesKey,
+	})
+
+	h := sha256.New()
+	h.Write([]byte(content))
+	for _, ds := range cr.Spec.Datasources {
+		if ds.CredentialsSecret == "" {
+			continue
+		}
+		container.Env = append(container.Env,
+			secretKeyEnvVar(datasourceEnvPrefix(ds.Name)+"_USERNAME", ds.CredentialsSecret, corev1.BasicAuthUsernameKey),
+			secretKeyEnvVar(datasourceEnvPrefix(ds.Name)+"_PASSWORD", ds.CredentialsSecret, corev1.BasicAuthPasswordKey),
+		)
+		secret := &corev1.Secret{}
+		err := r.client.Get(context.TODO(), types.NamespacedName{Name: ds.CredentialsSecret, Namespace: cr.Namespace}, secret)
+		if err != nil {
+			return fmt.Errorf("failed to get Secret %s referenced by datasource %s: %v", ds.CredentialsSecret, ds.Name, err)
+		}
+		h.Write([]byte{0})
+		h.Write(secret.Data[corev1.BasicAuthUsernameKey])
+		h.Write([]byte{0})
+		h.Write(secret.Data[corev1.BasicAuthPasswordKey])
+	}
+
+	setPodAnnotation(template, datasourcesHashAnnotation, hex.EncodeToString(h.Sum(nil)))
+	return nil
+}
+
+// secretKeyEnvVar returns an environment variable read from a key of a Secret
+func secretKeyEnvVar(name, secretName, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+				Key:                  key,
+			},
+		},
+	}
+}
@@ -0,0 +1,100 @@
+package wildfly
+
+import (
+	"strings"
+	"testing"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+)
+
+func TestDatasourceEnvPrefix(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"example-db", "DS_EXAMPLE_DB"},
+		{"example_db", "DS_EXAMPLE_DB"},
+		{"ExampleDS", "DS_EXAMPLEDS"},
+		{"db.2", "DS_DB_2"},
+	}
+	for _, tt := range tests {
+		if got := datasourceEnvPrefix(tt.name); got != tt.want {
+			t.Errorf("%s: got prefix %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidateDatasources(t *testing.T) {
+	tests := []struct {
+		name        string
+		datasources []wildflyv1alpha1.WildflyDatasourceDefinition
+		wantErr     bool
+	}{
+		{"none", nil, false},
+		{"pool bounds", []wildflyv1alpha1.WildflyDatasourceDefinition{{Name: "a", MinPoolSize: 5, MaxPoolSize: 20}}, false},
+		{"equal pool bounds", []wildflyv1alpha1.WildflyDatasourceDefinition{{Name: "a", MinPoolSize: 5, MaxPoolSize: 5}}, false},
+		{"minimum only", []wildflyv1alpha1.WildflyDatasourceDefinition{{Name: "a", MinPoolSize: 5}}, false},
+		{"minimum over maximum", []wildflyv1alpha1.WildflyDatasourceDefinition{{Name: "a", MinPoolSize: 20, MaxPoolSize: 5}}, true},
+		{"same prefix with credentials", []wildflyv1alpha1.WildflyDatasourceDefinition{
+			{Name: "my-ds", CredentialsSecret: "a"},
+			{Name: "my_ds", CredentialsSecret: "b"},
+		}, true},
+		{"same prefix without credentials", []wildflyv1alpha1.WildflyDatasourceDefinition{
+			{Name: "my-ds", CredentialsSecret: "a"},
+			{Name: "my_ds"},
+		}, false},
+	}
+	for _, tt := range tests {
+		cr := &wildflyv1alpha1.Wildfly{}
+		cr.Spec.Datasources = tt.datasources
+		err := validateDatasources(cr)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: got error %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRenderDatasources(t *testing.T) {
+	tests := []struct {
+		name       string
+		datasource wildflyv1alpha1.WildflyDatasourceDefinition
+		want       []string
+		notWant    []string
+	}{
+		{
+			name: "escaped URL",
+			datasource: wildflyv1alpha1.WildflyDatasourceDefinition{Name: "example-db", JNDIName: "java:jboss/datasources/ExampleDS",
+				Driver: "postgresql", ConnectionURL: "jdbc:postgresql://db:5432/example?ssl=true&user=<x>"},
+			want: []string{`jndi-name="java:jboss/datasources/ExampleDS"`, `pool-name="example-db"`,
+				"<connection-url>jdbc:postgresql://db:5432/example?ssl=true&amp;user=&lt;x&gt;</connection-url>"},
+			notWant: []string{"<pool>", "<security>"},
+		},
+		{
+			name: "pool and credentials",
+			datasource: wildflyv1alpha1.WildflyDatasourceDefinition{Name: "example-db", JNDIName: "java:/ExampleDS",
+				Driver: "h2", ConnectionURL: "jdbc:h2:mem:test", MaxPoolSize: 20, CredentialsSecret: "example-db-credentials"},
+			want: []string{"<max-pool-size>20</max-pool-size>", "<user-name>${env.DS_EXAMPLE_DB_USERNAME}</user-name>",
+				"<password>${env.DS_EXAMPLE_DB_PASSWORD}</password>"},
+			notWant: []string{"<min-pool-size>", "example-db-credentials"},
+		},
+	}
+	for _, tt := range tests {
+		cr := &wildflyv1alpha1.Wildfly{}
+		cr.Spec.Datasources = []wildflyv1alpha1.WildflyDatasourceDefinition{tt.datasource}
+		got, err := renderDatasources(cr)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		for _, s := range tt.want {
+			if !strings.Contains(got, s) {
+				t.Errorf("%s: %q not found in\n%s", tt.name, s, got)
+			}
+		}
+		for _, s := range tt.notWant {
+			if strings.Contains(got, s) {
+				t.Errorf("%s: unexpected %q in\n%s", tt.name, s, got)
+			}
+		}
+	}
+}
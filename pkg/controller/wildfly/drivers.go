@@ -0,0 +1,209 @@
+package wildfly
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	modulesPath           = "/opt/jboss/wildfly/modules"
+	driversPath           = "/drivers"
+	driversVolumeName     = volumePrefix + "drivers"
+	driversHashAnnotation = annotationPrefix + "drivers-hash"
+	driversContainerName  = "wildfly-drivers"
+	mavenRepositoryURL    = "https://repo1.maven.org/maven2"
+)
+
+var (
+	moduleNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+(\.[A-Za-z0-9_-]+)*$`)
+	driverNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+	classNamePattern  = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*(\.[A-Za-z_$][A-Za-z0-9_$]*)*$`)
+)
+
+// validateDrivers checks that every driver has a name, a valid module name and exactly one
+// source
+func validateDrivers(cr *wildflyv1alpha1.Wildfly) error {
+	names := map[string]bool{}
+	for _, driver := range cr.Spec.Drivers {
+		if !driverNamePattern.MatchString(driver.Name) {
+			return fmt.Errorf("invalid driver name %q", driver.Name)
+		}
+		if names[driver.Name] {
+			return fmt.Errorf("duplicate driver %s", driver.Name)
+		}
+		names[driver.Name] = true
+
+		if !moduleNamePattern.MatchString(driver.Module) {
+			return fmt.Errorf("invalid module name %q of driver %s", driver.Module, driver.Name)
+		}
+		if (driver.Maven == "") == (driver.Image == "") {
+			return fmt.Errorf("driver %s must have exactly one of maven or image", driver.Name)
+		}
+		if driver.Maven != "" && len(strings.Split(driver.Maven, ":")) != 3 {
+			return fmt.Errorf("driver %s must have maven coordinates groupId:artifactId:version", driver.Name)
+		}
+		if driver.Image != "" && driver.Path == "" {
+			return fmt.Errorf("driver %s must set the path of the jar in the image", driver.Name)
+		}
+		for _, class := range []string{driver.DriverClass, driver.XADatasourceClass} {
+			if class != "" && !classNamePattern.MatchString(class) {
+				return fmt.Errorf("invalid class name %q of driver %s", class, driver.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// moduleDir returns the directory of the main slot of a module, relative to the modules root
+func moduleDir(driver wildflyv1alpha1.WildflyDriver) string {
+	return strings.Replace(driver.Module, ".", "/", -1) + "/main"
+}
+
+// driverJar returns the file name of the jar of a driver
+func driverJar(driver wildflyv1alpha1.WildflyDriver) string {
+	if driver.Maven != "" {
+		coordinates := strings.Split(driver.Maven, ":")
+		return coordinates[1] + "-" + coordinates[2] + ".jar"
+	}
+	return path.Base(driver.Path)
+}
+
+// driverURL returns the URL of the jar of a driver in its Maven repository
+func driverURL(driver wildflyv1alpha1.WildflyDriver) string {
+	repository := driver.Repository
+	if repository == "" {
+		repository = mavenRepositoryURL
+	}
+	coordinates := strings.Split(driver.Maven, ":")
+	return strings.TrimSuffix(repository, "/") + "/" + strings.Replace(coordinates[0], ".", "/", -1) + "/" +
+		coordinates[1] + "/" + coordinates[2] + "/" + driverJar(driver)
+}
+
+// moduleXML returns the descriptor of the module of a driver
+func moduleXML(driver wildflyv1alpha1.WildflyDriver) (string, error) {
+	module, err := escapeXML(driver.Module)
+	if err != nil {
+		return "", err
+	}
+	jar, err := escapeXML(driverJar(driver))
+	if err != nil {
+		return "", err
+	}
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<module xmlns="urn:jboss:module:1.5" name="` + module + `">
+    <resources>
+        <resource-root path="` + jar + `"/>
+    </resources>
+    <dependencies>
+        <module name="javax.api"/>
+        <module name="javax.transaction.api"/>
+    </dependencies>
+</module>
+`, nil
+}
+
+// modulesScript returns the shell script laying out the modules of the drivers: the jars
+// resolved from Maven are downloaded, the others have been copied by the image init containers,
+// then the module descriptors are written next to them.
+func modulesScript(cr *wildflyv1alpha1.Wildfly) (string, error) {
+	lines := []string{"set -e"}
+	for _, driver := range cr.Spec.Drivers {
+		dir := driversPath + "/" + moduleDir(driver)
+		lines = append(lines, "mkdir -p "+shellQuote(dir))
+		if driver.Maven != "" {
+			lines = append(lines, "echo "+shellQuote("Downloading "+driverURL(driver)),
+				"curl -fsSL -o "+shellQuote(dir+"/"+driverJar(driver))+" "+shellQuote(driverURL(driver)))
+		}
+		descriptor, err := moduleXML(driver)
+		if err != nil {
+			return "", err
+		}
+		lines = append(lines, "printf '%s' "+shellQuote(descriptor)+" > "+shellQuote(dir+"/module.xml"))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// driversScript returns the CLI script registering the drivers in the datasources subsystem.
+// The names have been validated, they do not need to be quoted.
+func driversScript(cr *wildflyv1alpha1.Wildfly) string {
+	var commands []string
+	for _, driver := range cr.Spec.Drivers {
+		params := []string{"driver-name=" + driver.Name, "driver-module-name=" + driver.Module}
+		if driver.DriverClass != "" {
+			params = append(params, "driver-class-name="+driver.DriverClass)
+		}
+		if driver.XADatasourceClass != "" {
+			params = append(params, "driver-xa-datasource-class-name="+driver.XADatasourceClass)
+		}
+		commands = append(commands, "/subsystem=datasources/jdbc-driver="+driver.Name+":add("+strings.Join(params, ", ")+")")
+	}
+	return embeddedScript(cr, commands...)
+}
+
+// addDrivers installs the drivers as modules: an init container lays them out in a volume and
+// the directory of each module is mounted in the modules of the server. The drivers are
+// registered by the bootstrap scripts. The hash of the drivers is stamped on the pod template
+// so that any change triggers a rolling restart of the pods.
+func addDrivers(cr *wildflyv1alpha1.Wildfly, template *corev1.PodTemplateSpec) error {
+	if len(cr.Spec.Drivers) == 0 {
+		return nil
+	}
+	err := validateDrivers(cr)
+	if err != nil {
+		return err
+	}
+	script, err := modulesScript(cr)
+	if err != nil {
+		return err
+	}
+
+	h := sha256.New()
+	h.Write([]byte(script))
+	container := &template.Spec.Containers[0]
+	driversMount := corev1.VolumeMount{
+		Name:      driversVolumeName,
+		MountPath: driversPath,
+	}
+	for i, driver := range cr.Spec.Drivers {
+		if driver.Image != "" {
+			dir := driversPath + "/" + moduleDir(driver)
+			template.Spec.InitContainers = append(template.Spec.InitContainers, corev1.Container{
+				Name:         volumePrefix + "driver-" + strconv.Itoa(i),
+				Image:        driver.Image,
+				Command:      []string{"/bin/sh", "-c", "mkdir -p " + shellQuote(dir) + " && cp " + shellQuote(driver.Path) + " " + shellQuote(dir+"/")},
+				VolumeMounts: []corev1.VolumeMount{driversMount},
+			})
+			h.Write([]byte(driver.Image + "\x00" + driver.Path + "\x00"))
+		}
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      driversVolumeName,
+			MountPath: modulesPath + "/" + moduleDir(driver),
+			SubPath:   moduleDir(driver),
+			ReadOnly:  true,
+		})
+	}
+
+	template.Spec.InitContainers = append(template.Spec.InitContainers, corev1.Container{
+		Name:         driversContainerName,
+		Image:        container.Image,
+		Command:      []string{"/bin/sh", "-c", script},
+		VolumeMounts: []corev1.VolumeMount{driversMount},
+	})
+	template.Spec.Volumes = append(template.Spec.Volumes, corev1.Volume{
+		Name: driversVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	})
+
+	setPodAnnotation(template, driversHashAnnotation, hex.EncodeToString(h.Sum(nil)))
+	return nil
+}
@@ -0,0 +1,165 @@
+package wildfly
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	javaOptsEnvVar    = "JAVA_OPTS"
+	envHashAnnotation = annotationPrefix + "env-hash"
+)
+
+// systemPropertyFlags returns the -D flags of the system properties, sorted by name so that
+// the pod template is stable, followed by the log directory of the pod when the logs volume
+// is shared
+func systemPropertyFlags(cr *wildflyv1alpha1.Wildfly) []string {
+	names := []string{}
+	for name := range cr.Spec.SystemProperties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	flags := []string{}
+	for _, name := range names {
+		flags = append(flags, "-D"+name+"="+cr.Spec.SystemProperties[name])
+	}
+	return append(flags, logDirFlags(cr)...)
+}
+
+// validateSystemProperties checks that the system properties can be passed as flags
+func validateSystemProperties(cr *wildflyv1alpha1.Wildfly) error {
+	for name := range cr.Spec.SystemProperties {
+		if name == "" || strings.ContainsAny(name, "= \t\n") {
+			return fmt.Errorf("invalid system property name %q", name)
+		}
+	}
+	return nil
+}
+
+// javaOpts returns the JVM options passed in JAVA_OPTS, nil when the defaults of
+// standalone.conf are kept. The system properties are given to the default command as server
+// arguments, they only go to JAVA_OPTS with a custom command.
+func javaOpts(cr *wildflyv1alpha1.Wildfly) []string {
+	var opts []string
+	if cr.Spec.JVM != nil {
+		opts = append(opts, jvmOpts(cr)...)
+	}
+	if cr.Spec.Cmd != nil {
+		opts = append(opts, systemPropertyFlags(cr)...)
+	}
+	if len(opts) == 0 {
+		return nil
+	}
+	if cr.Spec.JVM == nil {
+		opts = append(append([]string{}, standaloneConfMemoryOpts...), opts...)
+	}
+	return append(append([]string{}, standaloneConfOpts...), opts...)
+}
+
+// addEnv sets the environment variables and the sources of Spec.Env and Spec.EnvFrom on the
+// wildfly container, before the ones added by the operator so that they cannot be overridden.
+// The JVM options are appended to a JAVA_OPTS of Spec.Env. The hash of the ConfigMaps and
+// Secrets of Spec.EnvFrom is stamped on the pod template, since the kubelet only reads them
+// when a container starts. The name of the pod comes first when the log directory of the
+// server depends on it.
+func (r *ReconcileWildfly) addEnv(cr *wildflyv1alpha1.Wildfly, template *corev1.PodTemplateSpec) error {
+	err := validateSystemProperties(cr)
+	if err != nil {
+		return err
+	}
+
+	container := &template.Spec.Containers[0]
+	if sharedLogs(cr) {
+		container.Env = append(container.Env, corev1.EnvVar{
+			Name: podNameEnvVar,
+			ValueFrom: &corev1.EnvVarSource{
+				FieldRef: &corev1.ObjectFieldSelector{
+					APIVersion: "v1",
+					FieldPath:  "metadata.name",
+				},
+			},
+		})
+	}
+	for _, env := range cr.Spec.Env {
+		env = *env.DeepCopy()
+		// The API server defaults the version of the field selectors
+		if env.ValueFrom != nil && env.ValueFrom.FieldRef != nil && env.ValueFrom.FieldRef.APIVersion == "" {
+			env.ValueFrom.FieldRef.APIVersion = "v1"
+		}
+		container.Env = append(container.Env, env)
+	}
+
+	if opts := javaOpts(cr); len(opts) > 0 {
+		var found *corev1.EnvVar
+		for i := range container.Env {
+			if container.Env[i].Name == javaOptsEnvVar {
+				found = &container.Env[i]
+			}
+		}
+		switch {
+		case found == nil:
+			container.Env = append(container.Env, corev1.EnvVar{Name: javaOptsEnvVar, Value: strings.Join(opts, " ")})
+		case found.ValueFrom != nil:
+			return fmt.Errorf("the JVM options cannot be added to %s, which is read from a ConfigMap or Secret", javaOptsEnvVar)
+		default:
+			found.Value = strings.TrimSpace(found.Value + " " + strings.Join(opts, " "))
+		}
+	}
+
+	if len(cr.Spec.EnvFrom) == 0 {
+		return nil
+	}
+	container.EnvFrom = append(container.EnvFrom, cr.Spec.EnvFrom...)
+	h := sha256.New()
+	for _, source := range cr.Spec.EnvFrom {
+		hash, err := r.envSourceHash(cr, source)
+		if err != nil {
+			return err
+		}
+		h.Write([]byte(hash))
+		h.Write([]byte{0})
+	}
+	setPodAnnotation(template, envHashAnnotation, hex.EncodeToString(h.Sum(nil)))
+	return nil
+}
+
+// envSourceHash returns the hash of the ConfigMap or Secret of an environment source. A
+// missing optional source has an empty hash.
+func (r *ReconcileWildfly) envSourceHash(cr *wildflyv1alpha1.Wildfly, source corev1.EnvFromSource) (string, error) {
+	switch {
+	case source.ConfigMapRef != nil:
+		cm := &corev1.ConfigMap{}
+		err := r.client.Get(context.TODO(), types.NamespacedName{Name: source.ConfigMapRef.Name, Namespace: cr.Namespace}, cm)
+		if errors.IsNotFound(err) && source.ConfigMapRef.Optional != nil && *source.ConfigMapRef.Optional {
+			return "", nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to get ConfigMap %s referenced by Wildfly %s: %v", source.ConfigMapRef.Name, cr.Name, err)
+		}
+		return configMapHash(cm), nil
+	case source.SecretRef != nil:
+		secret := &corev1.Secret{}
+		err := r.client.Get(context.TODO(), types.NamespacedName{Name: source.SecretRef.Name, Namespace: cr.Namespace}, secret)
+		if errors.IsNotFound(err) && source.SecretRef.Optional != nil && *source.SecretRef.Optional {
+			return "", nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to get Secret %s referenced by Wildfly %s: %v", source.SecretRef.Name, cr.Name, err)
+		}
+		data := map[string]string{}
+		for k, v := range secret.Data {
+			data[k] = string(v)
+		}
+		return dataHash(data), nil
+	}
+	return "", nil
+}
@@ -0,0 +1,91 @@
+package wildfly
+
+import (
+	"reflect"
+	"testing"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+)
+
+func TestSystemPropertyFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		properties map[string]string
+		storage    *wildflyv1alpha1.WildflyStorage
+		want       []string
+	}{
+		{"none", nil, nil, []string{}},
+		{"sorted by name", map[string]string{"b": "2", "a": "1"}, nil, []string{"-Da=1", "-Db=2"}},
+		{"shared logs", map[string]string{"a": "1"}, &wildflyv1alpha1.WildflyStorage{Logs: rwxClaim},
+			[]string{"-Da=1", "-Djboss.server.log.dir=" + logsPath + "/$(POD_NAME)"}},
+	}
+	for _, tt := range tests {
+		cr := &wildflyv1alpha1.Wildfly{}
+		cr.Spec.SystemProperties = tt.properties
+		cr.Spec.Storage = tt.storage
+		if got := systemPropertyFlags(cr); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got flags %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidateSystemProperties(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{"valid", "jboss.node.name", false},
+		{"empty", "", true},
+		{"equal sign", "a=b", true},
+		{"space", "a b", true},
+		{"newline", "a\nb", true},
+	}
+	for _, tt := range tests {
+		cr := &wildflyv1alpha1.Wildfly{}
+		cr.Spec.SystemProperties = map[string]string{tt.key: "value"}
+		err := validateSystemProperties(cr)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: got error %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestJavaOpts(t *testing.T) {
+	standalone := []string{"-Djava.net.preferIPv4Stack=true", "-Djboss.modules.system.pkgs=org.jboss.byteman",
+		"-Djava.awt.headless=true"}
+	memory := []string{"-Xms64m", "-Xmx512m", "-XX:MetaspaceSize=96M", "-XX:MaxMetaspaceSize=256m"}
+	jvm := []string{"-XX:MaxRAMPercentage=50", "-XX:MetaspaceSize=96M", "-XX:MaxMetaspaceSize=256m"}
+	join := func(parts ...[]string) []string {
+		var opts []string
+		for _, p := range parts {
+			opts = append(opts, p...)
+		}
+		return opts
+	}
+
+	tests := []struct {
+		name       string
+		jvm        *wildflyv1alpha1.WildflyJVM
+		cmd        []string
+		properties map[string]string
+		want       []string
+	}{
+		{"defaults of standalone.conf", nil, nil, map[string]string{"a": "1"}, nil},
+		{"JVM settings", &wildflyv1alpha1.WildflyJVM{}, nil, map[string]string{"a": "1"}, join(standalone, jvm)},
+		{"custom command", nil, []string{"/bin/run.sh"}, map[string]string{"a": "1"},
+			join(standalone, memory, []string{"-Da=1"})},
+		{"custom command without properties", nil, []string{"/bin/run.sh"}, nil, nil},
+		{"JVM settings and custom command", &wildflyv1alpha1.WildflyJVM{}, []string{"/bin/run.sh"}, map[string]string{"a": "1"},
+			join(standalone, jvm, []string{"-Da=1"})},
+	}
+	for _, tt := range tests {
+		cr := &wildflyv1alpha1.Wildfly{}
+		cr.Spec.JVM = tt.jvm
+		cr.Spec.Cmd = tt.cmd
+		cr.Spec.SystemProperties = tt.properties
+		if got := javaOpts(cr); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got options %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,377 @@
+package wildfly
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	exposePortDefault = int32(8080)
+	// exposeTLSPortDefault is the HTTPS listener reached by the Routes which do not
+	// terminate TLS at the router
+	exposeTLSPortDefault = int32(8443)
+)
+
+// routeGVK is the kind of the OpenShift Routes, handled as unstructured objects since the
+// OpenShift API types are not vendored
+var routeGVK = schema.GroupVersionKind{Group: "route.openshift.io", Version: "v1", Kind: "Route"}
+
+// routeAPIAvailable reports whether the cluster serves the OpenShift Route API
+func routeAPIAvailable(mapper meta.RESTMapper) bool {
+	_, err := mapper.RESTMapping(routeGVK.GroupKind(), routeGVK.Version)
+	return err == nil
+}
+
+// routeTermination returns the TLS termination of the Route, edge by default when a TLS
+// Secret is set
+func routeTermination(cr *wildflyv1alpha1.Wildfly) wildflyv1alpha1.WildflyTLSTermination {
+	if cr.Spec.Expose.Termination == "" && cr.Spec.Expose.TLSSecret != "" {
+		return wildflyv1alpha1.TerminationEdge
+	}
+	return cr.Spec.Expose.Termination
+}
+
+// tlsToServers reports whether the Route sends TLS traffic to the servers
+func tlsToServers(termination wildflyv1alpha1.WildflyTLSTermination) bool {
+	return termination == wildflyv1alpha1.TerminationReencrypt || termination == wildflyv1alpha1.TerminationPassthrough
+}
+
+// validateExpose checks that the servers listen for HTTPS when the Route does not terminate
+// TLS at the router
+func validateExpose(cr *wildflyv1alpha1.Wildfly) error {
+	if cr.Spec.Expose == nil {
+		return nil
+	}
+	if termination := routeTermination(cr); tlsToServers(termination) && !httpsEnabled(cr) {
+		return fmt.Errorf("the %s termination needs the HTTPS listener of the servers, set Spec.HTTPS", termination)
+	}
+	return nil
+}
+
+// exposePort returns the Service port exposed through the Ingress
+func exposePort(cr *wildflyv1alpha1.Wildfly) int32 {
+	if cr.Spec.Expose.Port != 0 {
+		return cr.Spec.Expose.Port
+	}
+	return exposePortDefault
+}
+
+// routePort returns the Service port targeted by the Route, the HTTPS port by default when
+// the Route sends TLS traffic to the servers
+func routePort(cr *wildflyv1alpha1.Wildfly) int32 {
+	if cr.Spec.Expose.Port == 0 && tlsToServers(routeTermination(cr)) {
+		return exposeTLSPortDefault
+	}
+	return exposePort(cr)
+}
+
+// reconcileExpose creates or updates the Route or the Ingress exposing the Wildfly, removes
+// the ones not needed anymore and records the exposed host in the status.
+func (r *ReconcileWildfly) reconcileExpose(cr *wildflyv1alpha1.Wildfly) error {
+	var ingress *extensionsv1beta1.Ingress
+	var route *unstructured.Unstructured
+	err := validateExpose(cr)
+	if err != nil {
+		log.Printf("Invalid exposure of Wildfly %s/%s: %v\n", cr.Namespace, cr.Name, err)
+		return err
+	}
+	if cr.Spec.Expose != nil {
+		if r.routeAvailable {
+			route, err = r.newWildflyRoute(cr)
+			if err != nil {
+				log.Printf("Failed to define Wildfly Route: %v\n", err)
+				return err
+			}
+		} else {
+			ingress = r.newWildflyIngress(cr)
+		}
+	}
+
+	cr.Status.Host = ""
+	err = r.reconcileIngress(cr, ingress)
+	if err != nil {
+		return err
+	}
+	if r.routeAvailable {
+		return r.reconcileRoute(cr, route)
+	}
+	return nil
+}
+
+// newWildflyIngress returns the Ingress routing the host and path of Spec.Expose to the Service
+func (r *ReconcileWildfly) newWildflyIngress(cr *wildflyv1alpha1.Wildfly) *extensionsv1beta1.Ingress {
+	expose := cr.Spec.Expose
+	ingress := &extensionsv1beta1.Ingress{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "extensions/v1beta1",
+			Kind:       "Ingress",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        cr.Name,
+			Namespace:   cr.Namespace,
+			Labels:      map[string]string{"app": cr.Name},
+			Annotations: expose.Annotations,
+		},
+		Spec: extensionsv1beta1.IngressSpec{
+			Rules: []extensionsv1beta1.IngressRule{{
+				Host: expose.Host,
+				IngressRuleValue: extensionsv1beta1.IngressRuleValue{
+					HTTP: &extensionsv1beta1.HTTPIngressRuleValue{
+						Paths: []extensionsv1beta1.HTTPIngressPath{{
+							Path: expose.Path,
+							Backend: extensionsv1beta1.IngressBackend{
+								ServiceName: cr.Name,
+								ServicePort: intstr.FromInt(int(exposePort(cr))),
+							},
+						}},
+					},
+				},
+			}},
+		},
+	}
+	if expose.TLSSecret != "" {
+		tls := extensionsv1beta1.IngressTLS{SecretName: expose.TLSSecret}
+		if expose.Host != "" {
+			tls.Hosts = []string{expose.Host}
+		}
+		ingress.Spec.TLS = []extensionsv1beta1.IngressTLS{tls}
+	}
+	controllerutil.SetControllerReference(cr, ingress, r.scheme)
+	return ingress
+}
+
+// reconcileIngress creates or updates the given Ingress, or removes the Ingress owned by the
+// Wildfly when desired is nil. The annotations of Spec.Expose are set on the Ingress, the ones
+// added by the ingress controllers are preserved.
+func (r *ReconcileWildfly) reconcileIngress(cr *wildflyv1alpha1.Wildfly, desired *extensionsv1beta1.Ingress) error {
+	found := &extensionsv1beta1.Ingress{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}, found)
+	if err != nil && !errors.IsNotFound(err) {
+		log.Printf("Failed to get Ingress: %v\n", err)
+		return err
+	}
+	exists := err == nil
+
+	if desired == nil {
+		if exists && metav1.IsControlledBy(found, cr) {
+			log.Printf("Deleting Wildfly Ingress: %s/%s\n", found.Namespace, found.Name)
+			return r.client.Delete(context.TODO(), found)
+		}
+		return nil
+	}
+
+	cr.Status.Host = desired.Spec.Rules[0].Host
+	if !exists {
+		log.Printf("Creating a new Wildfly Ingress: %s/%s\n", desired.Namespace, desired.Name)
+		err = r.client.Create(context.TODO(), desired)
+		if err != nil {
+			log.Printf("Failed to create new Wildfly Ingress: %v\n", err)
+		}
+		return err
+	}
+
+	changed := false
+	for k, v := range desired.Annotations {
+		if found.Annotations == nil {
+			found.Annotations = map[string]string{}
+		}
+		if found.Annotations[k] != v {
+			found.Annotations[k] = v
+			changed = true
+		}
+	}
+	if !reflect.DeepEqual(found.Spec, desired.Spec) {
+		found.Spec = desired.Spec
+		changed = true
+	}
+	if changed {
+		log.Printf("Updating Wildfly Ingress: %s/%s\n", found.Namespace, found.Name)
+		err = r.client.Update(context.TODO(), found)
+		if err != nil {
+			log.Printf("Failed to update Wildfly Ingress: %v\n", err)
+		}
+		return err
+	}
+	return nil
+}
+
+// newWildflyRoute returns the Route exposing the Service. The certificates of the TLS Secret
+// are inlined in the Route, which cannot reference a Secret. With the reencrypt termination
+// the CA of the Secret of Spec.HTTPS is the destination CA certificate.
+func (r *ReconcileWildfly) newWildflyRoute(cr *wildflyv1alpha1.Wildfly) (*unstructured.Unstructured, error) {
+	expose := cr.Spec.Expose
+	spec := map[string]interface{}{
+		"to": map[string]interface{}{
+			"kind":   "Service",
+			"name":   cr.Name,
+			"weight": int64(100),
+		},
+		"port": map[string]interface{}{
+			"targetPort": int64(routePort(cr)),
+		},
+		"wildcardPolicy": "None",
+	}
+	if expose.Host != "" {
+		spec["host"] = expose.Host
+	}
+	if expose.Path != "" {
+		spec["path"] = expose.Path
+	}
+
+	termination := routeTermination(cr)
+	if termination != "" {
+		tls := map[string]interface{}{
+			"termination": string(termination),
+		}
+		if termination != wildflyv1alpha1.TerminationPassthrough {
+			tls["insecureEdgeTerminationPolicy"] = "Redirect"
+		}
+		if expose.TLSSecret != "" && termination != wildflyv1alpha1.TerminationPassthrough {
+			secret := &corev1.Secret{}
+			err := r.client.Get(context.TODO(), types.NamespacedName{Name: expose.TLSSecret, Namespace: cr.Namespace}, secret)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get TLS Secret %s: %v", expose.TLSSecret, err)
+			}
+			tls["certificate"] = string(secret.Data[corev1.TLSCertKey])
+			tls["key"] = string(secret.Data[corev1.TLSPrivateKeyKey])
+			if ca, ok := secret.Data["ca.crt"]; ok {
+				tls["caCertificate"] = string(ca)
+			}
+		}
+		if termination == wildflyv1alpha1.TerminationReencrypt {
+			// The router validates the certificate served by the servers against the CA of
+			// their own TLS Secret
+			secret, err := r.getTLSSecret(cr)
+			if err != nil {
+				return nil, err
+			}
+			if ca, ok := secret.Data["ca.crt"]; ok {
+				tls["destinationCACertificate"] = string(ca)
+			}
+		}
+		spec["tls"] = tls
+	}
+
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(routeGVK)
+	route.SetName(cr.Name)
+	route.SetNamespace(cr.Namespace)
+	route.SetLabels(map[string]string{"app": cr.Name})
+	route.SetAnnotations(expose.Annotations)
+	route.Object["spec"] = spec
+	controllerutil.SetControllerReference(cr, route, r.scheme)
+	return route, nil
+}
+
+// reconcileRoute creates or updates the given Route, or removes the Route owned by the
+// Wildfly when desired is nil. The host generated by the router is kept when Spec.Expose
+// does not set one.
+func (r *ReconcileWildfly) reconcileRoute(cr *wildflyv1alpha1.Wildfly, desired *unstructured.Unstructured) error {
+	found := &unstructured.Unstructured{}
+	found.SetGroupVersionKind(routeGVK)
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}, found)
+	if err != nil && !errors.IsNotFound(err) {
+		log.Printf("Failed to get Route: %v\n", err)
+		return err
+	}
+	exists := err == nil
+
+	if desired == nil {
+		if exists && metav1.IsControlledBy(found, cr) {
+			log.Printf("Deleting Wildfly Route: %s/%s\n", found.GetNamespace(), found.GetName())
+			return r.client.Delete(context.TODO(), found)
+		}
+		return nil
+	}
+
+	if !exists {
+		log.Printf("Creating a new Wildfly Route: %s/%s\n", desired.GetNamespace(), desired.GetName())
+		err = r.client.Create(context.TODO(), desired)
+		if err != nil {
+			log.Printf("Failed to create new Wildfly Route: %v\n", err)
+		}
+		return err
+	}
+
+	desiredSpec, _, _ := unstructured.NestedMap(desired.Object, "spec")
+	foundSpec, _, _ := unstructured.NestedMap(found.Object, "spec")
+	if _, ok := desiredSpec["host"]; !ok && foundSpec["host"] != nil {
+		desiredSpec["host"] = foundSpec["host"]
+	}
+	cr.Status.Host, _, _ = unstructured.NestedString(found.Object, "spec", "host")
+
+	changed := false
+	annotations := found.GetAnnotations()
+	for k, v := range desired.GetAnnotations() {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		if annotations[k] != v {
+			annotations[k] = v
+			changed = true
+		}
+	}
+	if !reflect.DeepEqual(normalize(foundSpec), normalize(desiredSpec)) {
+		changed = true
+	}
+	if changed {
+		found.SetAnnotations(annotations)
+		found.Object["spec"] = desiredSpec
+		log.Printf("Updating Wildfly Route: %s/%s\n", found.GetNamespace(), found.GetName())
+		err = r.client.Update(context.TODO(), found)
+		if err != nil {
+			log.Printf("Failed to update Wildfly Route: %v\n", err)
+		}
+		return err
+	}
+	return nil
+}
+
+// normalize converts the numbers of an unstructured object to int64 so that objects decoded
+// from JSON compare equal to the ones built by the operator
+func normalize(obj interface{}) interface{} {
+	switch v := obj.(type) {
+	case map[string]interface{}:
+		m := map[string]interface{}{}
+		for k, e := range v {
+			m[k] = normalize(e)
+		}
+		return m
+	case []interface{}:
+		s := []interface{}{}
+		for _, e := range v {
+			s = append(s, normalize(e))
+		}
+		return s
+	case float64:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case int:
+		return int64(v)
+	default:
+		return v
+	}
+}
+
+// newRouteObject returns an empty Route to watch
+func newRouteObject() runtime.Object {
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(routeGVK)
+	return route
+}
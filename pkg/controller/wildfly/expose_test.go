@@ -0,0 +1,85 @@
+package wildfly
+
+import (
+	"testing"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+)
+
+// newExposedWildfly returns a Wildfly exposed with the given settings, with the HTTPS
+// listener when https is set
+func newExposedWildfly(expose wildflyv1alpha1.WildflyExpose, https bool) *wildflyv1alpha1.Wildfly {
+	cr := &wildflyv1alpha1.Wildfly{}
+	cr.Spec.Expose = &expose
+	if https {
+		cr.Spec.HTTPS = &wildflyv1alpha1.WildflyHTTPS{Secret: "example-tls"}
+	}
+	return cr
+}
+
+func TestRouteTermination(t *testing.T) {
+	tests := []struct {
+		name   string
+		expose wildflyv1alpha1.WildflyExpose
+		want   wildflyv1alpha1.WildflyTLSTermination
+	}{
+		{"plain", wildflyv1alpha1.WildflyExpose{}, ""},
+		{"edge by default with a TLS Secret", wildflyv1alpha1.WildflyExpose{TLSSecret: "tls"}, wildflyv1alpha1.TerminationEdge},
+		{"reencrypt", wildflyv1alpha1.WildflyExpose{TLSSecret: "tls", Termination: wildflyv1alpha1.TerminationReencrypt},
+			wildflyv1alpha1.TerminationReencrypt},
+		{"passthrough without Secret", wildflyv1alpha1.WildflyExpose{Termination: wildflyv1alpha1.TerminationPassthrough},
+			wildflyv1alpha1.TerminationPassthrough},
+	}
+	for _, tt := range tests {
+		if got := routeTermination(newExposedWildfly(tt.expose, false)); got != tt.want {
+			t.Errorf("%s: got termination %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidateExpose(t *testing.T) {
+	tests := []struct {
+		name    string
+		cr      *wildflyv1alpha1.Wildfly
+		wantErr bool
+	}{
+		{"not exposed", &wildflyv1alpha1.Wildfly{}, false},
+		{"plain", newExposedWildfly(wildflyv1alpha1.WildflyExpose{}, false), false},
+		{"edge", newExposedWildfly(wildflyv1alpha1.WildflyExpose{TLSSecret: "tls"}, false), false},
+		{"reencrypt without HTTPS", newExposedWildfly(wildflyv1alpha1.WildflyExpose{Termination: wildflyv1alpha1.TerminationReencrypt}, false), true},
+		{"reencrypt with HTTPS", newExposedWildfly(wildflyv1alpha1.WildflyExpose{Termination: wildflyv1alpha1.TerminationReencrypt}, true), false},
+		{"passthrough without HTTPS", newExposedWildfly(wildflyv1alpha1.WildflyExpose{Termination: wildflyv1alpha1.TerminationPassthrough}, false), true},
+		{"passthrough with HTTPS", newExposedWildfly(wildflyv1alpha1.WildflyExpose{Termination: wildflyv1alpha1.TerminationPassthrough}, true), false},
+	}
+	for _, tt := range tests {
+		err := validateExpose(tt.cr)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: got error %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRoutePort(t *testing.T) {
+	tests := []struct {
+		name        string
+		expose      wildflyv1alpha1.WildflyExpose
+		wantRoute   int32
+		wantIngress int32
+	}{
+		{"default", wildflyv1alpha1.WildflyExpose{}, 8080, 8080},
+		{"edge", wildflyv1alpha1.WildflyExpose{TLSSecret: "tls"}, 8080, 8080},
+		{"reencrypt", wildflyv1alpha1.WildflyExpose{Termination: wildflyv1alpha1.TerminationReencrypt}, 8443, 8080},
+		{"passthrough", wildflyv1alpha1.WildflyExpose{Termination: wildflyv1alpha1.TerminationPassthrough}, 8443, 8080},
+		{"reencrypt with port", wildflyv1alpha1.WildflyExpose{Termination: wildflyv1alpha1.TerminationReencrypt, Port: 9443}, 9443, 9443},
+		{"plain with port", wildflyv1alpha1.WildflyExpose{Port: 8081}, 8081, 8081},
+	}
+	for _, tt := range tests {
+		cr := newExposedWildfly(tt.expose, true)
+		if got := routePort(cr); got != tt.wantRoute {
+			t.Errorf("%s: got Route port %d, want %d", tt.name, got, tt.wantRoute)
+		}
+		if got := exposePort(cr); got != tt.wantIngress {
+			t.Errorf("%s: got Ingress port %d, want %d", tt.name, got, tt.wantIngress)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package wildfly
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"log"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	pkcs12 "software.sslmate.com/src/go-pkcs12"
+)
+
+const (
+	keystorePath             = "/etc/wildfly/keystore"
+	keystoreVolumeName       = volumePrefix + "keystore"
+	keystoreKey              = "keystore.p12"
+	keystorePasswordKey      = "password"
+	keystorePasswordEnvVar   = "WILDFLY_KEYSTORE_PASSWORD"
+	keystoreSourceAnnotation = annotationPrefix + "source-hash"
+	httpsHashAnnotation      = annotationPrefix + "https-hash"
+)
+
+// httpsEnabled reports whether the HTTPS listener is configured with a certificate
+func httpsEnabled(cr *wildflyv1alpha1.Wildfly) bool {
+	return cr.Spec.HTTPS != nil && cr.Spec.HTTPS.Secret != ""
+}
+
+// keystoreSecretName returns the name of the Secret holding the generated keystore
+func keystoreSecretName(cr *wildflyv1alpha1.Wildfly) string {
+	return cr.Name + "-keystore"
+}
+
+// httpsScript returns the CLI script defining the Elytron keystore, key manager and SSL
+// context, and switching the https-listener from the legacy ApplicationRealm to them
+func httpsScript(cr *wildflyv1alpha1.Wildfly) string {
+	credential := `credential-reference={clear-text="${env.` + keystorePasswordEnvVar + `}"}`
+	return embeddedScript(cr,
+		"batch",
+		"/subsystem=elytron/key-store=wildfly-operator-keystore:add(path="+keystorePath+"/"+keystoreKey+", type=PKCS12, "+credential+")",
+		"/subsystem=elytron/key-manager=wildfly-operator-key-manager:add(key-store=wildfly-operator-keystore, "+credential+")",
+		"/subsystem=elytron/server-ssl-context=wildfly-operator-ssl-context:add(key-manager=wildfly-operator-key-manager)",
+		"/subsystem=undertow/server=default-server/https-listener=https:undefine-attribute(name=security-realm)",
+		"/subsystem=undertow/server=default-server/https-listener=https:write-attribute(name=ssl-context, value=wildfly-operator-ssl-context)",
+		"run-batch",
+	)
+}
+
+// tlsSecretHash returns the hash of the certificate and the key of a TLS Secret
+func tlsSecretHash(secret *corev1.Secret) string {
+	h := sha256.New()
+	h.Write(secret.Data[corev1.TLSCertKey])
+	h.Write([]byte{0})
+	h.Write(secret.Data[corev1.TLSPrivateKeyKey])
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// getTLSSecret returns the TLS Secret referenced by Spec.HTTPS
+func (r *ReconcileWildfly) getTLSSecret(cr *wildflyv1alpha1.Wildfly) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: cr.Spec.HTTPS.Secret, Namespace: cr.Namespace}, secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get TLS Secret %s referenced by Wildfly %s: %v", cr.Spec.HTTPS.Secret, cr.Name, err)
+	}
+	return secret, nil
+}
+
+// encodeKeystore converts the PEM certificate chain, leaf first, and the key of a TLS Secret
+// to a PKCS12 keystore protected by password, with the AES and SHA-256 based algorithms
+func encodeKeystore(certPEM, keyPEM []byte, password string) ([]byte, error) {
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	var chain []*x509.Certificate
+	for _, der := range pair.Certificate {
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, cert)
+	}
+	return pkcs12.Modern.Encode(pair.PrivateKey, chain[0], chain[1:], password)
+}
+
+// reconcileKeystore generates the Secret holding the PKCS12 keystore converted from the TLS
+// Secret and its password, and removes it when HTTPS is not configured anymore. The keystore
+// is only regenerated when the certificate or the key change, since every encoding differs.
+func (r *ReconcileWildfly) reconcileKeystore(cr *wildflyv1alpha1.Wildfly) error {
+	found := &corev1.Secret{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: keystoreSecretName(cr), Namespace: cr.Namespace}, found)
+	if err != nil && !errors.IsNotFound(err) {
+		log.Printf("Failed to get keystore Secret: %v\n", err)
+		return err
+	}
+	exists := err == nil
+
+	if !httpsEnabled(cr) {
+		if exists && metav1.IsControlledBy(found, cr) {
+			log.Printf("Deleting keystore Secret: %s/%s\n", found.Namespace, found.Name)
+			return r.client.Delete(context.TODO(), found)
+		}
+		return nil
+	}
+
+	source, err := r.getTLSSecret(cr)
+	if err != nil {
+		log.Printf("Failed to generate keystore: %v\n", err)
+		return err
+	}
+	hash := tlsSecretHash(source)
+	if exists && found.Annotations[keystoreSourceAnnotation] == hash {
+		return nil
+	}
+
+	password := ""
+	if exists {
+		password = string(found.Data[keystorePasswordKey])
+	}
+	if password == "" {
+		b := make([]byte, 18)
+		_, err = rand.Read(b)
+		if err != nil {
+			return err
+		}
+		password = hex.EncodeToString(b)
+	}
+	store, err := encodeKeystore(source.Data[corev1.TLSCertKey], source.Data[corev1.TLSPrivateKeyKey], password)
+	if err != nil {
+		log.Printf("Failed to convert TLS Secret %s to a keystore: %v\n", source.Name, err)
+		return err
+	}
+	data := map[string][]byte{
+		keystoreKey:         store,
+		keystorePasswordKey: []byte(password),
+	}
+
+	if exists {
+		if found.Annotations == nil {
+			found.Annotations = map[string]string{}
+		}
+		found.Annotations[keystoreSourceAnnotation] = hash
+		found.Data = data
+		log.Printf("Updating keystore Secret: %s/%s\n", found.Namespace, found.Name)
+		return r.client.Update(context.TODO(), found)
+	}
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        keystoreSecretName(cr),
+			Namespace:   cr.Namespace,
+			Labels:      map[string]string{"app": cr.Name},
+			Annotations: map[string]string{keystoreSourceAnnotation: hash},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: data,
+	}
+	controllerutil.SetControllerReference(cr, secret, r.scheme)
+	log.Printf("Creating keystore Secret: %s/%s\n", secret.Namespace, secret.Name)
+	return r.client.Create(context.TODO(), secret)
+}
+
+// addHTTPS mounts the generated keystore and passes its password to the wildfly and bootstrap
+// containers. The hash of the TLS Secret is stamped on the pod template so that a renewed
+// certificate triggers a rolling restart of the pods.
+func (r *ReconcileWildfly) addHTTPS(cr *wildflyv1alpha1.Wildfly, template *corev1.PodTemplateSpec) error {
+	if !httpsEnabled(cr) {
+		return nil
+	}
+	source, err := r.getTLSSecret(cr)
+	if err != nil {
+		return err
+	}
+
+	mode := configMapDefaultMode
+	template.Spec.Volumes = append(template.Spec.Volumes, corev1.Volume{
+		Name: keystoreVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName:  keystoreSecretName(cr),
+				DefaultMode: &mode,
+			},
+		},
+	})
+
+	password := secretKeyEnvVar(keystorePasswordEnvVar, keystoreSecretName(cr), keystorePasswordKey)
+	container := &template.Spec.Containers[0]
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      keystoreVolumeName,
+		MountPath: keystorePath,
+		ReadOnly:  true,
+	})
+	container.Env = append(container.Env, password)
+	if c := findContainer(template.Spec.InitContainers, bootstrapContainerName); c != nil {
+		c.Env = append(c.Env, password)
+	}
+
+	setPodAnnotation(template, httpsHashAnnotation, tlsSecretHash(source))
+	return nil
+}
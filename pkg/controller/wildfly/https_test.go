@@ -0,0 +1,139 @@
+package wildfly
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	pkcs12 "software.sslmate.com/src/go-pkcs12"
+)
+
+// newCertificate returns a DER certificate of the key, signed by the parent or self-signed
+func newCertificate(t *testing.T, cn string, key crypto.Signer, parent *x509.Certificate, parentKey crypto.Signer) []byte {
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  parent == nil,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	if err != nil {
+		t.Fatalf("creating certificate: %v", err)
+	}
+	return der
+}
+
+// pemEncode returns the PEM blocks of a certificate chain and of a key
+func pemEncode(t *testing.T, chain [][]byte, key crypto.PrivateKey) ([]byte, []byte) {
+	var certPEM []byte
+	for _, der := range chain {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	}
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshaling key: %v", err)
+	}
+	return certPEM, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8})
+}
+
+func TestEncodeKeystore(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []crypto.Signer{rsaKey, ecKey} {
+		der := newCertificate(t, "wildfly.example.com", key, nil, nil)
+		certPEM, keyPEM := pemEncode(t, [][]byte{der}, key)
+
+		store, err := encodeKeystore(certPEM, keyPEM, "changeit")
+		if err != nil {
+			t.Fatalf("encodeKeystore(%T): %v", key, err)
+		}
+		decodedKey, cert, err := pkcs12.Decode(store, "changeit")
+		if err != nil {
+			t.Fatalf("decoding the %T keystore: %v", key, err)
+		}
+		if !bytes.Equal(cert.Raw, der) {
+			t.Errorf("%T: the decoded certificate differs", key)
+		}
+		if !reflect.DeepEqual(decodedKey, key) {
+			t.Errorf("%T: the decoded key differs", key)
+		}
+		if _, _, err := pkcs12.Decode(store, "wrong"); err == nil {
+			t.Errorf("%T: the keystore was decoded with a wrong password", key)
+		}
+	}
+}
+
+func TestEncodeKeystoreChain(t *testing.T) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caDER := newCertificate(t, "ca", caKey, nil, nil)
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafDER := newCertificate(t, "wildfly.example.com", key, ca, caKey)
+	certPEM, keyPEM := pemEncode(t, [][]byte{leafDER, caDER}, key)
+
+	store, err := encodeKeystore(certPEM, keyPEM, "changeit")
+	if err != nil {
+		t.Fatalf("encodeKeystore: %v", err)
+	}
+	_, leaf, cas, err := pkcs12.DecodeChain(store, "changeit")
+	if err != nil {
+		t.Fatalf("decoding the keystore: %v", err)
+	}
+	if !bytes.Equal(leaf.Raw, leafDER) {
+		t.Errorf("the leaf certificate differs")
+	}
+	if len(cas) != 1 || !bytes.Equal(cas[0].Raw, caDER) {
+		t.Errorf("got %d CA certificates, want the CA", len(cas))
+	}
+}
+
+func TestEncodeKeystoreMismatchedKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM, _ := pemEncode(t, [][]byte{newCertificate(t, "wildfly.example.com", key, nil, nil)}, key)
+	_, keyPEM := pemEncode(t, nil, other)
+	if _, err := encodeKeystore(certPEM, keyPEM, "changeit"); err == nil {
+		t.Errorf("a key not matching the certificate was accepted")
+	}
+	if _, err := encodeKeystore(nil, keyPEM, "changeit"); err == nil {
+		t.Errorf("an empty certificate chain was accepted")
+	}
+}
@@ -0,0 +1,161 @@
+package wildfly
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	heapPercentageDefault = 50
+	mebibyte              = 1024 * 1024
+	// jvmInvalidReason is the reason of the Degraded condition set when the JVM settings do
+	// not fit in the container
+	jvmInvalidReason = "InvalidJVMSettings"
+)
+
+var (
+	maxMetaspaceSizeDefault = resource.MustParse("256Mi")
+	// standaloneConfOpts are the options set by standalone.conf when JAVA_OPTS is empty, they
+	// are kept when the operator sets JAVA_OPTS
+	standaloneConfOpts = []string{"-Djava.net.preferIPv4Stack=true", "-Djboss.modules.system.pkgs=org.jboss.byteman",
+		"-Djava.awt.headless=true"}
+	// standaloneConfMemoryOpts are the memory settings of standalone.conf, replaced by the ones
+	// of Spec.JVM
+	standaloneConfMemoryOpts = []string{"-Xms64m", "-Xmx512m", "-XX:MetaspaceSize=96M", "-XX:MaxMetaspaceSize=256m"}
+	gcOpts                   = map[wildflyv1alpha1.WildflyGC]string{
+		wildflyv1alpha1.GCG1:       "-XX:+UseG1GC",
+		wildflyv1alpha1.GCParallel: "-XX:+UseParallelGC",
+		wildflyv1alpha1.GCSerial:   "-XX:+UseSerialGC",
+	}
+)
+
+// heapPercentage returns the percentage of the memory limit given to the heap
+func heapPercentage(cr *wildflyv1alpha1.Wildfly) int64 {
+	if cr.Spec.JVM.HeapPercentage == 0 {
+		return heapPercentageDefault
+	}
+	return int64(cr.Spec.JVM.HeapPercentage)
+}
+
+// maxMetaspaceSize returns the maximum size of the metaspace
+func maxMetaspaceSize(cr *wildflyv1alpha1.Wildfly) resource.Quantity {
+	if cr.Spec.JVM.MaxMetaspaceSize == nil {
+		return maxMetaspaceSizeDefault
+	}
+	return *cr.Spec.JVM.MaxMetaspaceSize
+}
+
+// memoryLimit returns the memory limit of the container in bytes, 0 when there is none
+func memoryLimit(cr *wildflyv1alpha1.Wildfly) int64 {
+	limit, ok := cr.Spec.Resources.Limits[corev1.ResourceMemory]
+	if !ok {
+		return 0
+	}
+	return limit.Value()
+}
+
+// validateJVM checks the JVM settings, and that the heap and the metaspace fit in the memory
+// limit of the container
+func validateJVM(cr *wildflyv1alpha1.Wildfly) error {
+	if cr.Spec.JVM == nil {
+		return nil
+	}
+	if cr.Spec.JVM.HeapPercentage < 0 || cr.Spec.JVM.HeapPercentage > 100 {
+		return fmt.Errorf("the heap percentage must be between 1 and 100, or 0 for the default, got %d", cr.Spec.JVM.HeapPercentage)
+	}
+	if _, ok := gcOpts[cr.Spec.JVM.GC]; cr.Spec.JVM.GC != "" && !ok {
+		return fmt.Errorf("unknown garbage collector %q", cr.Spec.JVM.GC)
+	}
+	metaspace := maxMetaspaceSize(cr)
+	if metaspace.Value() < mebibyte {
+		return fmt.Errorf("the maximum metaspace size must be at least 1Mi, got %s", metaspace.String())
+	}
+	limit := memoryLimit(cr)
+	if limit == 0 {
+		return nil
+	}
+	heap := limit * heapPercentage(cr) / 100
+	if heap < mebibyte {
+		return fmt.Errorf("a heap of %d%% of the memory limit is less than 1Mi", heapPercentage(cr))
+	}
+	if heap+metaspace.Value() > limit {
+		return fmt.Errorf("a heap of %dMi (%d%% of the memory limit) and a metaspace of %dMi do not fit in the memory limit of %dMi",
+			heap/mebibyte, heapPercentage(cr), metaspace.Value()/mebibyte, limit/mebibyte)
+	}
+	return nil
+}
+
+// jvmOpts returns the JVM options of Spec.JVM. The maximum heap is computed from the memory
+// limit, or left to the JVM as a percentage of the memory of the container without limit.
+func jvmOpts(cr *wildflyv1alpha1.Wildfly) []string {
+	var opts []string
+	if limit := memoryLimit(cr); limit > 0 {
+		opts = append(opts, fmt.Sprintf("-Xmx%dm", limit*heapPercentage(cr)/100/mebibyte))
+	} else {
+		opts = append(opts, fmt.Sprintf("-XX:MaxRAMPercentage=%d", heapPercentage(cr)))
+	}
+	metaspace := maxMetaspaceSize(cr)
+	opts = append(opts, "-XX:MetaspaceSize=96M", fmt.Sprintf("-XX:MaxMetaspaceSize=%dm", metaspace.Value()/mebibyte))
+	if gc, ok := gcOpts[cr.Spec.JVM.GC]; ok {
+		opts = append(opts, gc)
+	}
+	return append(opts, cr.Spec.JVM.Args...)
+}
+
+// desiredResources returns the resources of the wildfly container, with the requests
+// defaulted to the limits as done by the API server
+func desiredResources(cr *wildflyv1alpha1.Wildfly) corev1.ResourceRequirements {
+	resources := *cr.Spec.Resources.DeepCopy()
+	for name, limit := range resources.Limits {
+		if _, ok := resources.Requests[name]; ok {
+			continue
+		}
+		if resources.Requests == nil {
+			resources.Requests = corev1.ResourceList{}
+		}
+		resources.Requests[name] = limit
+	}
+	return resources
+}
+
+// resourcesEqual compares resource requirements by value, quantities parsed from different
+// representations are not deeply equal
+func resourcesEqual(a, b corev1.ResourceRequirements) bool {
+	return resourceListEqual(a.Limits, b.Limits) && resourceListEqual(a.Requests, b.Requests)
+}
+
+// resourceListEqual compares resource lists by value
+func resourceListEqual(a, b corev1.ResourceList) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, qa := range a {
+		qb, ok := b[name]
+		if !ok || qa.Cmp(qb) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// reportInvalidJVM sets the Degraded condition of the Wildfly when its JVM settings are
+// invalid. The servers are left untouched until the settings are fixed, then the condition
+// is computed again from the workload.
+func (r *ReconcileWildfly) reportInvalidJVM(cr *wildflyv1alpha1.Wildfly, previous *wildflyv1alpha1.WildflyStatus, jvmErr error) error {
+	log.Printf("Invalid JVM settings for Wildfly %s/%s: %v\n", cr.Namespace, cr.Name, jvmErr)
+	setCondition(&cr.Status, wildflyv1alpha1.WildflyDegraded, corev1.ConditionTrue, jvmInvalidReason, jvmErr.Error())
+	if reflect.DeepEqual(previous, &cr.Status) {
+		return nil
+	}
+	err := r.client.Status().Update(context.TODO(), cr)
+	if err != nil {
+		log.Printf("Failed to update Wildfly status: %v\n", err)
+	}
+	return err
+}
@@ -0,0 +1,105 @@
+package wildfly
+
+import (
+	"reflect"
+	"testing"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// newJVMWildfly returns a Wildfly with the given JVM settings and memory limit
+func newJVMWildfly(jvm *wildflyv1alpha1.WildflyJVM, limit string) *wildflyv1alpha1.Wildfly {
+	cr := &wildflyv1alpha1.Wildfly{}
+	cr.Spec.JVM = jvm
+	if limit != "" {
+		cr.Spec.Resources.Limits = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse(limit)}
+	}
+	return cr
+}
+
+func TestValidateJVM(t *testing.T) {
+	metaspace := resource.MustParse("512Ki")
+	largeMetaspace := resource.MustParse("600Mi")
+
+	tests := []struct {
+		name    string
+		cr      *wildflyv1alpha1.Wildfly
+		wantErr bool
+	}{
+		{"no JVM settings", newJVMWildfly(nil, "64Mi"), false},
+		{"defaults without limit", newJVMWildfly(&wildflyv1alpha1.WildflyJVM{}, ""), false},
+		{"defaults within the limit", newJVMWildfly(&wildflyv1alpha1.WildflyJVM{}, "1Gi"), false},
+		{"negative heap percentage", newJVMWildfly(&wildflyv1alpha1.WildflyJVM{HeapPercentage: -1}, ""), true},
+		{"heap percentage over 100", newJVMWildfly(&wildflyv1alpha1.WildflyJVM{HeapPercentage: 101}, ""), true},
+		{"unknown garbage collector", newJVMWildfly(&wildflyv1alpha1.WildflyJVM{GC: "CMS"}, ""), true},
+		{"known garbage collector", newJVMWildfly(&wildflyv1alpha1.WildflyJVM{GC: wildflyv1alpha1.GCSerial}, ""), false},
+		{"metaspace under 1Mi", newJVMWildfly(&wildflyv1alpha1.WildflyJVM{MaxMetaspaceSize: &metaspace}, ""), true},
+		{"heap under 1Mi", newJVMWildfly(&wildflyv1alpha1.WildflyJVM{HeapPercentage: 1}, "64Mi"), true},
+		{"heap and metaspace over the limit", newJVMWildfly(&wildflyv1alpha1.WildflyJVM{HeapPercentage: 80}, "1Gi"), true},
+		{"large metaspace over the limit", newJVMWildfly(&wildflyv1alpha1.WildflyJVM{MaxMetaspaceSize: &largeMetaspace}, "1Gi"), true},
+	}
+	for _, tt := range tests {
+		err := validateJVM(tt.cr)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: got error %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestJVMOpts(t *testing.T) {
+	metaspace := resource.MustParse("128Mi")
+
+	tests := []struct {
+		name string
+		cr   *wildflyv1alpha1.Wildfly
+		want []string
+	}{
+		{"defaults without limit", newJVMWildfly(&wildflyv1alpha1.WildflyJVM{}, ""),
+			[]string{"-XX:MaxRAMPercentage=50", "-XX:MetaspaceSize=96M", "-XX:MaxMetaspaceSize=256m"}},
+		{"defaults with limit", newJVMWildfly(&wildflyv1alpha1.WildflyJVM{}, "1Gi"),
+			[]string{"-Xmx512m", "-XX:MetaspaceSize=96M", "-XX:MaxMetaspaceSize=256m"}},
+		{"heap percentage", newJVMWildfly(&wildflyv1alpha1.WildflyJVM{HeapPercentage: 60}, "2Gi"),
+			[]string{"-Xmx1228m", "-XX:MetaspaceSize=96M", "-XX:MaxMetaspaceSize=256m"}},
+		{"metaspace, garbage collector and arguments", newJVMWildfly(&wildflyv1alpha1.WildflyJVM{
+			MaxMetaspaceSize: &metaspace,
+			GC:               wildflyv1alpha1.GCG1,
+			Args:             []string{"-XX:+ExitOnOutOfMemoryError"},
+		}, ""),
+			[]string{"-XX:MaxRAMPercentage=50", "-XX:MetaspaceSize=96M", "-XX:MaxMetaspaceSize=128m", "-XX:+UseG1GC",
+				"-XX:+ExitOnOutOfMemoryError"}},
+	}
+	for _, tt := range tests {
+		if got := jvmOpts(tt.cr); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got options %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestResourcesEqual(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b corev1.ResourceRequirements
+		want bool
+	}{
+		{"empty", corev1.ResourceRequirements{}, corev1.ResourceRequirements{}, true},
+		{"same value in other units",
+			corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")}},
+			corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1024Mi")}},
+			true},
+		{"different values",
+			corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")}},
+			corev1.ResourceRequirements{Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")}},
+			false},
+		{"missing request",
+			corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}},
+			corev1.ResourceRequirements{},
+			false},
+	}
+	for _, tt := range tests {
+		if got := resourcesEqual(tt.a, tt.b); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
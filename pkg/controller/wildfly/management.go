@@ -0,0 +1,133 @@
+package wildfly
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+	"github.com/giannisalinetti/wildfly-operator/pkg/controller/servers"
+	"github.com/giannisalinetti/wildfly-operator/pkg/management"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// managementEnabled reports whether the operator is allowed to talk to the management
+// interface of the servers
+func managementEnabled(cr *wildflyv1alpha1.Wildfly) bool {
+	return servers.ManagementEnabled(cr)
+}
+
+// Server states reported by the server-state attribute of the root resource
+const (
+	serverStateRunning         = "running"
+	serverStateReloadRequired  = "reload-required"
+	serverStateRestartRequired = "restart-required"
+	serverStateUnknown         = "unknown"
+)
+
+// serverStatePollInterval is how often the runtime state of the servers is refreshed, since
+// changes reported by the management interface do not generate any Kubernetes event
+const serverStatePollInterval = 30 * time.Second
+
+// readServers queries the server-state and running-mode of the server in each pod
+func (r *ReconcileWildfly) readServers(cr *wildflyv1alpha1.Wildfly, pods []corev1.Pod) []wildflyv1alpha1.WildflyServer {
+	var states []wildflyv1alpha1.WildflyServer
+	clients, err := servers.NewClients(r.client, cr, pods)
+	if err != nil {
+		log.Printf("Failed to create management clients: %v\n", err)
+		for _, pod := range pods {
+			states = append(states, wildflyv1alpha1.WildflyServer{Pod: pod.Name, ServerState: serverStateUnknown, Message: err.Error()})
+		}
+		return states
+	}
+
+	for _, pod := range pods {
+		server := wildflyv1alpha1.WildflyServer{Pod: pod.Name, ServerState: serverStateUnknown}
+		c, ok := clients[pod.Name]
+		if !ok {
+			server.Message = "pod is not running"
+			states = append(states, server)
+			continue
+		}
+
+		results, err := c.ExecuteComposite(
+			management.ReadAttribute(management.Address{}, "server-state"),
+			management.ReadAttribute(management.Address{}, "running-mode"),
+		)
+		if err != nil {
+			server.Message = err.Error()
+			states = append(states, server)
+			continue
+		}
+		decodeString(results[0].Result, &server.ServerState)
+		decodeString(results[1].Result, &server.RunningMode)
+		states = append(states, server)
+	}
+	return states
+}
+
+// reconcileReload reloads the servers reporting reload-required in the status when the reload
+// policy is Automatic. A single server is reloaded per reconciliation, and only when every
+// replica is ready, so that the others keep serving while it reloads.
+func (r *ReconcileWildfly) reconcileReload(cr *wildflyv1alpha1.Wildfly) error {
+	if !managementEnabled(cr) || cr.Spec.Management.ReloadPolicy != wildflyv1alpha1.ReloadAutomatic {
+		return nil
+	}
+	reload := ""
+	for _, server := range cr.Status.Servers {
+		if server.ServerState == serverStateReloadRequired {
+			reload = server.Pod
+			break
+		}
+	}
+	if reload == "" {
+		return nil
+	}
+
+	pods, err := r.listPods(cr)
+	if err != nil {
+		log.Printf("Failed to list pods for Wildfly %s/%s: %v\n", cr.Namespace, cr.Name, err)
+		return err
+	}
+	if int32(len(pods)) < cr.Status.Replicas {
+		return nil
+	}
+	var target []corev1.Pod
+	for i := range pods {
+		if !servers.PodReady(&pods[i]) {
+			log.Printf("Postponing the reload of pod %s/%s until pod %s is ready\n", cr.Namespace, reload, pods[i].Name)
+			return nil
+		}
+		if pods[i].Name == reload {
+			target = append(target, pods[i])
+		}
+	}
+	if len(target) == 0 {
+		return nil
+	}
+
+	clients, err := servers.NewClients(r.client, cr, target)
+	if err != nil {
+		log.Printf("Failed to create management clients: %v\n", err)
+		return err
+	}
+	c, ok := clients[reload]
+	if !ok {
+		return nil
+	}
+	log.Printf("Reloading server in pod %s/%s\n", cr.Namespace, reload)
+	err = c.Reload()
+	if err != nil {
+		log.Printf("Failed to reload server in pod %s/%s: %v\n", cr.Namespace, reload, err)
+		return err
+	}
+	return nil
+}
+
+// decodeString decodes a JSON string result, leaving the target untouched on failure
+func decodeString(data []byte, s *string) {
+	var v string
+	if err := json.Unmarshal(data, &v); err == nil {
+		*s = v
+	}
+}
@@ -0,0 +1,157 @@
+package wildfly
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"reflect"
+	"regexp"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+	"github.com/giannisalinetti/wildfly-operator/pkg/controller/servers"
+	"github.com/giannisalinetti/wildfly-operator/pkg/management"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	managementRealm                = "ManagementRealm"
+	managementUsersKey             = "mgmt-users.properties"
+	managementUserPath             = bootstrapPath + "/management"
+	managementUserVolumeName       = volumePrefix + "management-user"
+	managementUserHashAnnotation   = annotationPrefix + "management-user-hash"
+	managementServicePortName      = "management"
+	managementUserSecretNameSuffix = "-management-user"
+	managementPort                 = management.DefaultPort
+)
+
+// The user names are restricted to the characters which need no escaping in a properties file
+var managementUserPattern = regexp.MustCompile(`^[A-Za-z0-9_.@-]+$`)
+
+// managementUserSecretName returns the name of the Secret holding the hashed management user
+func managementUserSecretName(cr *wildflyv1alpha1.Wildfly) string {
+	return cr.Name + managementUserSecretNameSuffix
+}
+
+// managementUserEntry returns the line of mgmt-users.properties defining a user, in the format
+// written by add-user.sh: username=HEX(MD5(username:realm:password))
+func managementUserEntry(username, password string) string {
+	sum := md5.Sum([]byte(username + ":" + managementRealm + ":" + password))
+	return username + "=" + hex.EncodeToString(sum[:])
+}
+
+// managementUser returns the mgmt-users.properties entry of the user of the management Secret
+func (r *ReconcileWildfly) managementUser(cr *wildflyv1alpha1.Wildfly) (string, error) {
+	username, password, err := servers.Credentials(r.client, cr)
+	if err != nil {
+		return "", err
+	}
+	if !managementUserPattern.MatchString(username) {
+		return "", fmt.Errorf("invalid management user name %q", username)
+	}
+	return managementUserEntry(username, password), nil
+}
+
+// reconcileManagementUser creates or updates the Secret holding the hashed management user,
+// and removes it when the management interface is not enabled anymore. The clear text
+// password never leaves the Secret of the user.
+func (r *ReconcileWildfly) reconcileManagementUser(cr *wildflyv1alpha1.Wildfly) error {
+	found := &corev1.Secret{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: managementUserSecretName(cr), Namespace: cr.Namespace}, found)
+	if err != nil && !errors.IsNotFound(err) {
+		log.Printf("Failed to get management user Secret: %v\n", err)
+		return err
+	}
+	exists := err == nil
+
+	if !managementEnabled(cr) {
+		if exists && metav1.IsControlledBy(found, cr) {
+			log.Printf("Deleting management user Secret: %s/%s\n", found.Namespace, found.Name)
+			return r.client.Delete(context.TODO(), found)
+		}
+		return nil
+	}
+
+	entry, err := r.managementUser(cr)
+	if err != nil {
+		log.Printf("Failed to provision management user: %v\n", err)
+		return err
+	}
+	data := map[string][]byte{managementUsersKey: []byte(entry)}
+
+	if exists {
+		if reflect.DeepEqual(found.Data, data) {
+			return nil
+		}
+		found.Data = data
+		log.Printf("Updating management user Secret: %s/%s\n", found.Namespace, found.Name)
+		return r.client.Update(context.TODO(), found)
+	}
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      managementUserSecretName(cr),
+			Namespace: cr.Namespace,
+			Labels:    map[string]string{"app": cr.Name},
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: data,
+	}
+	controllerutil.SetControllerReference(cr, secret, r.scheme)
+	log.Printf("Creating management user Secret: %s/%s\n", secret.Namespace, secret.Name)
+	return r.client.Create(context.TODO(), secret)
+}
+
+// addManagementUser mounts the hashed management user in the bootstrap container, which adds
+// it to mgmt-users.properties. The hash of the user is stamped on the pod template so that
+// changed credentials trigger a rolling restart of the pods.
+func (r *ReconcileWildfly) addManagementUser(cr *wildflyv1alpha1.Wildfly, template *corev1.PodTemplateSpec) error {
+	if !managementEnabled(cr) {
+		return nil
+	}
+	entry, err := r.managementUser(cr)
+	if err != nil {
+		return err
+	}
+
+	mode := configMapDefaultMode
+	template.Spec.Volumes = append(template.Spec.Volumes, corev1.Volume{
+		Name: managementUserVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName:  managementUserSecretName(cr),
+				DefaultMode: &mode,
+			},
+		},
+	})
+	addBootstrapMount(template, corev1.VolumeMount{
+		Name:      managementUserVolumeName,
+		MountPath: managementUserPath,
+		ReadOnly:  true,
+	})
+
+	sum := sha256.Sum256([]byte(entry))
+	setPodAnnotation(template, managementUserHashAnnotation, hex.EncodeToString(sum[:]))
+	return nil
+}
+
+// managementExposed reports whether the management port is published on the Service
+func managementExposed(cr *wildflyv1alpha1.Wildfly) bool {
+	return managementEnabled(cr) && cr.Spec.Management.Expose
+}
+
+// newManagementServicePort returns the Service port of the management interface
+func newManagementServicePort() corev1.ServicePort {
+	port := newServicePort(managementPort, corev1.ProtocolTCP)
+	port.Name = managementServicePortName
+	return port
+}
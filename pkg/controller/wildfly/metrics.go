@@ -0,0 +1,184 @@
+package wildfly
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const metricsNamespace = "wildfly_operator"
+
+// Phases of the reconciliation of a Wildfly, reported by the reconcile errors metric
+const (
+	phaseFetch         = "fetch"
+	phaseConfiguration = "configuration"
+	phaseStorage       = "storage"
+	phaseDeployment    = "deployment"
+	phaseService       = "service"
+	phaseExpose        = "expose"
+	phaseMonitoring    = "monitoring"
+	phaseStatus        = "status"
+	phaseReload        = "reload"
+)
+
+var (
+	reconcilePhases = []string{phaseFetch, phaseConfiguration, phaseStorage, phaseDeployment, phaseService,
+		phaseExpose, phaseMonitoring, phaseStatus, phaseReload}
+	conditionTypes = []wildflyv1alpha1.WildflyConditionType{wildflyv1alpha1.WildflyAvailable,
+		wildflyv1alpha1.WildflyProgressing, wildflyv1alpha1.WildflyDegraded}
+	conditionStatuses = []corev1.ConditionStatus{corev1.ConditionTrue, corev1.ConditionFalse, corev1.ConditionUnknown}
+
+	reconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "reconcile_errors_total",
+		Help:      "Number of failed reconciliations of the Wildflies by phase.",
+	}, []string{"phase"})
+
+	wildfliesDesc = prometheus.NewDesc(metricsNamespace+"_wildflies",
+		"Number of Wildflies by condition and status of the condition.",
+		[]string{"condition", "status"}, nil)
+	desiredReplicasDesc = prometheus.NewDesc(metricsNamespace+"_desired_replicas",
+		"Number of replicas requested by a Wildfly.",
+		[]string{"namespace", "name"}, nil)
+	readyReplicasDesc = prometheus.NewDesc(metricsNamespace+"_ready_replicas",
+		"Number of ready replicas of a Wildfly.",
+		[]string{"namespace", "name"}, nil)
+	sinceLastReconcileDesc = prometheus.NewDesc(metricsNamespace+"_seconds_since_last_successful_reconcile",
+		"Seconds since the last successful reconciliation of a Wildfly, or since the start of the operator when it was never reconciled successfully.",
+		[]string{"namespace", "name"}, nil)
+	reconcileFailingDesc = prometheus.NewDesc(metricsNamespace+"_reconcile_failing",
+		"Whether the last reconciliation of a Wildfly failed.",
+		[]string{"namespace", "name"}, nil)
+)
+
+// wildflyCollector reports the state of the Wildflies read from the cache of the manager when
+// the metrics are scraped, so that deleted Wildflies do not leave stale series behind
+type wildflyCollector struct {
+	client  client.Client
+	started time.Time
+
+	mutex       sync.Mutex
+	lastSuccess map[types.NamespacedName]time.Time
+	failing     map[types.NamespacedName]bool
+}
+
+// newWildflyCollector returns a collector reading the Wildflies with the given client
+func newWildflyCollector(c client.Client) *wildflyCollector {
+	return &wildflyCollector{
+		client:      c,
+		started:     time.Now(),
+		lastSuccess: map[types.NamespacedName]time.Time{},
+		failing:     map[types.NamespacedName]bool{},
+	}
+}
+
+// registerMetrics registers the collectors of the Wildflies on the metrics endpoint of the
+// manager. The reconcile errors of every phase are initialized so that they can be alerted
+// on before the first failure.
+func registerMetrics(collector *wildflyCollector) error {
+	for _, phase := range reconcilePhases {
+		reconcileErrors.WithLabelValues(phase)
+	}
+	for _, c := range []prometheus.Collector{reconcileErrors, collector} {
+		err := metrics.Registry.Register(c)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reconciled records the outcome of the reconciliation of a Wildfly
+func (c *wildflyCollector) reconciled(name types.NamespacedName, err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.failing[name] = err != nil
+	if err == nil {
+		c.lastSuccess[name] = time.Now()
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *wildflyCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- wildfliesDesc
+	ch <- desiredReplicasDesc
+	ch <- readyReplicasDesc
+	ch <- sinceLastReconcileDesc
+	ch <- reconcileFailingDesc
+}
+
+// Collect implements prometheus.Collector. The outcomes recorded for the Wildflies which
+// do not exist anymore are dropped.
+func (c *wildflyCollector) Collect(ch chan<- prometheus.Metric) {
+	list := &wildflyv1alpha1.WildflyList{}
+	err := c.client.List(context.TODO(), &client.ListOptions{}, list)
+	if err != nil {
+		log.Printf("Failed to list Wildflies for the metrics: %v\n", err)
+		return
+	}
+
+	counts := map[wildflyv1alpha1.WildflyConditionType]map[corev1.ConditionStatus]int{}
+	for _, t := range conditionTypes {
+		counts[t] = map[corev1.ConditionStatus]int{}
+	}
+	now := time.Now()
+	listed := map[types.NamespacedName]bool{}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, cr := range list.Items {
+		for _, condition := range cr.Status.Conditions {
+			if _, ok := counts[condition.Type]; ok {
+				counts[condition.Type][condition.Status]++
+			}
+		}
+
+		ch <- prometheus.MustNewConstMetric(desiredReplicasDesc, prometheus.GaugeValue,
+			float64(desiredReplicas(&cr)), cr.Namespace, cr.Name)
+		ch <- prometheus.MustNewConstMetric(readyReplicasDesc, prometheus.GaugeValue,
+			float64(cr.Status.ReadyReplicas), cr.Namespace, cr.Name)
+
+		name := types.NamespacedName{Namespace: cr.Namespace, Name: cr.Name}
+		listed[name] = true
+		last, ok := c.lastSuccess[name]
+		if !ok {
+			last = c.started
+		}
+		ch <- prometheus.MustNewConstMetric(sinceLastReconcileDesc, prometheus.GaugeValue,
+			now.Sub(last).Seconds(), cr.Namespace, cr.Name)
+		failing := 0.0
+		if c.failing[name] {
+			failing = 1
+		}
+		ch <- prometheus.MustNewConstMetric(reconcileFailingDesc, prometheus.GaugeValue,
+			failing, cr.Namespace, cr.Name)
+	}
+	for name := range c.failing {
+		if !listed[name] {
+			delete(c.failing, name)
+			delete(c.lastSuccess, name)
+		}
+	}
+
+	for _, t := range conditionTypes {
+		for _, s := range conditionStatuses {
+			ch <- prometheus.MustNewConstMetric(wildfliesDesc, prometheus.GaugeValue,
+				float64(counts[t][s]), string(t), string(s))
+		}
+	}
+}
+
+// reconcileFailed counts a failed reconciliation in the given phase and returns the error to
+// requeue the request
+func reconcileFailed(phase string, err error) (reconcile.Result, error) {
+	reconcileErrors.WithLabelValues(phase).Inc()
+	return reconcile.Result{}, err
+}
@@ -0,0 +1,253 @@
+package wildfly
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"regexp"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	metricsPath            = "/metrics"
+	metricsServicePortName = "metrics"
+	// metricsLabel marks the metrics Service scraped by the ServiceMonitor, the other
+	// Services select the same pods and must not be scraped
+	metricsLabel = annotationPrefix + "metrics"
+)
+
+// serviceMonitorGVK is the kind of the Prometheus Operator ServiceMonitors, handled as
+// unstructured objects since the Prometheus Operator API types are not vendored
+var serviceMonitorGVK = schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "ServiceMonitor"}
+
+// intervalPattern matches the durations accepted by Prometheus, e.g. 30s or 1m30s
+var intervalPattern = regexp.MustCompile(`^([0-9]+(ms|s|m|h|d|w|y))+$`)
+
+// serviceMonitorAPIAvailable reports whether the cluster serves the ServiceMonitor API
+func serviceMonitorAPIAvailable(mapper meta.RESTMapper) bool {
+	_, err := mapper.RESTMapping(serviceMonitorGVK.GroupKind(), serviceMonitorGVK.Version)
+	return err == nil
+}
+
+// monitoringEnabled reports whether the metrics of the servers are published
+func monitoringEnabled(cr *wildflyv1alpha1.Wildfly) bool {
+	return cr.Spec.Monitoring != nil
+}
+
+// validateMonitoring checks the scrape interval of Spec.Monitoring
+func validateMonitoring(cr *wildflyv1alpha1.Wildfly) error {
+	if !monitoringEnabled(cr) || cr.Spec.Monitoring.Interval == "" {
+		return nil
+	}
+	if !intervalPattern.MatchString(cr.Spec.Monitoring.Interval) {
+		return fmt.Errorf("invalid scrape interval %q", cr.Spec.Monitoring.Interval)
+	}
+	return nil
+}
+
+// metricsServiceName returns the name of the Service publishing the metrics of the servers
+func metricsServiceName(cr *wildflyv1alpha1.Wildfly) string {
+	return cr.Name + "-metrics"
+}
+
+// newMetricsServicePort returns the Service port of the metrics endpoint
+func newMetricsServicePort() corev1.ServicePort {
+	port := newServicePort(managementPort, corev1.ProtocolTCP)
+	port.Name = metricsServicePortName
+	return port
+}
+
+// newMetricsService returns the Service publishing the metrics endpoint, labeled for the
+// ServiceMonitor. The metrics are served by the management interface, which is kept off the
+// Service of the applications.
+func (r *ReconcileWildfly) newMetricsService(cr *wildflyv1alpha1.Wildfly) *corev1.Service {
+	svc := &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      metricsServiceName(cr),
+			Namespace: cr.Namespace,
+			Labels: map[string]string{
+				"app":        cr.Name,
+				metricsLabel: "true",
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: map[string]string{"app": cr.Name},
+			Ports:    []corev1.ServicePort{newMetricsServicePort()},
+		},
+	}
+	controllerutil.SetControllerReference(cr, svc, r.scheme)
+	return svc
+}
+
+// reconcileMetricsService creates or updates the metrics Service when the monitoring is
+// enabled, and removes it otherwise
+func (r *ReconcileWildfly) reconcileMetricsService(cr *wildflyv1alpha1.Wildfly) error {
+	if monitoringEnabled(cr) {
+		_, _, err := r.reconcileService(r.newMetricsService(cr))
+		return err
+	}
+
+	found := &corev1.Service{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: metricsServiceName(cr), Namespace: cr.Namespace}, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if !metav1.IsControlledBy(found, cr) {
+		return nil
+	}
+	log.Printf("Deleting Wildfly metrics Service: %s/%s\n", found.Namespace, found.Name)
+	return r.client.Delete(context.TODO(), found)
+}
+
+// monitoringScript returns the CLI script opening the metrics endpoint to unauthenticated
+// requests. The subsystem serving it depends on the version of WildFly: the base metrics
+// subsystem since WildFly 19, the MicroProfile one before.
+func monitoringScript(cr *wildflyv1alpha1.Wildfly) string {
+	var commands []string
+	for _, subsystem := range []string{"metrics", "microprofile-metrics-smallrye"} {
+		commands = append(commands,
+			"if (outcome == success) of /subsystem="+subsystem+":read-resource",
+			"    /subsystem="+subsystem+":write-attribute(name=security-enabled, value=false)",
+			"end-if")
+	}
+	return embeddedScript(cr, commands...)
+}
+
+// newServiceMonitor returns the ServiceMonitor scraping the metrics Service
+func (r *ReconcileWildfly) newServiceMonitor(cr *wildflyv1alpha1.Wildfly) *unstructured.Unstructured {
+	endpoint := map[string]interface{}{
+		"port": metricsServicePortName,
+		"path": metricsPath,
+	}
+	if cr.Spec.Monitoring.Interval != "" {
+		endpoint["interval"] = cr.Spec.Monitoring.Interval
+	}
+	spec := map[string]interface{}{
+		"selector": map[string]interface{}{
+			"matchLabels": map[string]interface{}{
+				"app":        cr.Name,
+				metricsLabel: "true",
+			},
+		},
+		"endpoints": []interface{}{endpoint},
+	}
+
+	labels := map[string]string{}
+	for k, v := range cr.Spec.Monitoring.Labels {
+		labels[k] = v
+	}
+	labels["app"] = cr.Name
+
+	monitor := &unstructured.Unstructured{}
+	monitor.SetGroupVersionKind(serviceMonitorGVK)
+	monitor.SetName(cr.Name)
+	monitor.SetNamespace(cr.Namespace)
+	monitor.SetLabels(labels)
+	monitor.Object["spec"] = spec
+	controllerutil.SetControllerReference(cr, monitor, r.scheme)
+	return monitor
+}
+
+// reconcileMonitoring creates or updates the metrics Service and the ServiceMonitor of the
+// Wildfly, and removes them when the monitoring is disabled. No ServiceMonitor is created on
+// clusters without the Prometheus Operator, the metrics Service can still be scraped by other
+// means.
+func (r *ReconcileWildfly) reconcileMonitoring(cr *wildflyv1alpha1.Wildfly) error {
+	err := r.reconcileMetricsService(cr)
+	if err != nil {
+		return err
+	}
+
+	if !r.serviceMonitorAvailable {
+		if monitoringEnabled(cr) {
+			log.Printf("ServiceMonitor API not available, no ServiceMonitor created for Wildfly %s/%s\n", cr.Namespace, cr.Name)
+		}
+		return nil
+	}
+
+	found := &unstructured.Unstructured{}
+	found.SetGroupVersionKind(serviceMonitorGVK)
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}, found)
+	if err != nil && !errors.IsNotFound(err) {
+		log.Printf("Failed to get ServiceMonitor: %v\n", err)
+		return err
+	}
+	exists := err == nil
+
+	if !monitoringEnabled(cr) {
+		if exists && metav1.IsControlledBy(found, cr) {
+			log.Printf("Deleting Wildfly ServiceMonitor: %s/%s\n", found.GetNamespace(), found.GetName())
+			return r.client.Delete(context.TODO(), found)
+		}
+		return nil
+	}
+
+	err = validateMonitoring(cr)
+	if err != nil {
+		log.Printf("Invalid monitoring of Wildfly %s/%s: %v\n", cr.Namespace, cr.Name, err)
+		return err
+	}
+	desired := r.newServiceMonitor(cr)
+	if !exists {
+		log.Printf("Creating a new Wildfly ServiceMonitor: %s/%s\n", desired.GetNamespace(), desired.GetName())
+		err = r.client.Create(context.TODO(), desired)
+		if err != nil {
+			log.Printf("Failed to create new Wildfly ServiceMonitor: %v\n", err)
+		}
+		return err
+	}
+
+	changed := false
+	labels := found.GetLabels()
+	for k, v := range desired.GetLabels() {
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		if labels[k] != v {
+			labels[k] = v
+			changed = true
+		}
+	}
+	desiredSpec, _, _ := unstructured.NestedMap(desired.Object, "spec")
+	foundSpec, _, _ := unstructured.NestedMap(found.Object, "spec")
+	if !reflect.DeepEqual(normalize(foundSpec), normalize(desiredSpec)) {
+		changed = true
+	}
+	if changed {
+		found.SetLabels(labels)
+		found.Object["spec"] = desiredSpec
+		log.Printf("Updating Wildfly ServiceMonitor: %s/%s\n", found.GetNamespace(), found.GetName())
+		err = r.client.Update(context.TODO(), found)
+		if err != nil {
+			log.Printf("Failed to update Wildfly ServiceMonitor: %v\n", err)
+		}
+		return err
+	}
+	return nil
+}
+
+// newServiceMonitorObject returns an empty ServiceMonitor to watch
+func newServiceMonitorObject() runtime.Object {
+	monitor := &unstructured.Unstructured{}
+	monitor.SetGroupVersionKind(serviceMonitorGVK)
+	return monitor
+}
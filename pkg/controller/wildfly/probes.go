@@ -0,0 +1,115 @@
+package wildfly
+
+import (
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+	"github.com/giannisalinetti/wildfly-operator/pkg/management"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	jbossCliPath = "/opt/jboss/wildfly/bin/jboss-cli.sh"
+
+	livenessPath  = "/health/live"
+	readinessPath = "/health/ready"
+
+	// Defaults of the probe timings. The liveness probe waits for the server to boot.
+	livenessInitialDelayDefault  = int32(60)
+	readinessInitialDelayDefault = int32(10)
+	probePeriodDefault           = int32(10)
+	probeTimeoutDefault          = int32(5)
+	// jboss-cli.sh starts a JVM, so exec probes need a longer timeout
+	execProbeTimeoutDefault = int32(15)
+	probeFailureDefault     = int32(3)
+)
+
+// httpProbesEnabled reports whether the probes query the health endpoints of the management
+// interface, which then needs to be reachable by the kubelet
+func httpProbesEnabled(cr *wildflyv1alpha1.Wildfly) bool {
+	handler := desiredProbes(cr).Handler
+	return handler != wildflyv1alpha1.ProbeExec && handler != wildflyv1alpha1.ProbeNone
+}
+
+// desiredProbes returns the probes of the spec, the default HTTP probes when it sets none
+func desiredProbes(cr *wildflyv1alpha1.Wildfly) *wildflyv1alpha1.WildflyProbes {
+	if cr.Spec.Probes == nil {
+		return &wildflyv1alpha1.WildflyProbes{Handler: wildflyv1alpha1.ProbeHTTP}
+	}
+	return cr.Spec.Probes
+}
+
+// addProbes sets the liveness and readiness probes on the wildfly container, unless they are
+// disabled with the None handler
+func (r *ReconcileWildfly) addProbes(cr *wildflyv1alpha1.Wildfly, template *corev1.PodTemplateSpec) {
+	probes := desiredProbes(cr)
+	if probes.Handler == wildflyv1alpha1.ProbeNone {
+		return
+	}
+
+	livenessDelay := livenessInitialDelayDefault
+	if probes.StartupTimeoutSeconds > 0 {
+		livenessDelay = probes.StartupTimeoutSeconds
+	}
+
+	container := &template.Spec.Containers[0]
+	if probes.Handler == wildflyv1alpha1.ProbeExec {
+		// The liveness probe only checks that the server answers, the readiness probe also
+		// requires the server to be running
+		container.LivenessProbe = newProbe(probes.Liveness, execProbeHandler(jbossCliPath+
+			" --connect --commands=':read-attribute(name=server-state)'"), livenessDelay, execProbeTimeoutDefault)
+		container.ReadinessProbe = newProbe(probes.Readiness, execProbeHandler(jbossCliPath+
+			" --connect --commands=':read-attribute(name=server-state)' | grep -q running"), readinessInitialDelayDefault, execProbeTimeoutDefault)
+		return
+	}
+	container.LivenessProbe = newProbe(probes.Liveness, httpProbeHandler(livenessPath), livenessDelay, probeTimeoutDefault)
+	container.ReadinessProbe = newProbe(probes.Readiness, httpProbeHandler(readinessPath), readinessInitialDelayDefault, probeTimeoutDefault)
+}
+
+// newProbe returns a probe with the given handler and the timings of the spec. Every field
+// defaulted by the API server is set explicitly to avoid spurious updates of the Deployment.
+func newProbe(spec *wildflyv1alpha1.WildflyProbe, handler corev1.Handler, initialDelay, timeout int32) *corev1.Probe {
+	probe := &corev1.Probe{
+		Handler:             handler,
+		InitialDelaySeconds: initialDelay,
+		PeriodSeconds:       probePeriodDefault,
+		TimeoutSeconds:      timeout,
+		SuccessThreshold:    1,
+		FailureThreshold:    probeFailureDefault,
+	}
+	if spec == nil {
+		return probe
+	}
+	if spec.InitialDelaySeconds > 0 {
+		probe.InitialDelaySeconds = spec.InitialDelaySeconds
+	}
+	if spec.PeriodSeconds > 0 {
+		probe.PeriodSeconds = spec.PeriodSeconds
+	}
+	if spec.TimeoutSeconds > 0 {
+		probe.TimeoutSeconds = spec.TimeoutSeconds
+	}
+	if spec.FailureThreshold > 0 {
+		probe.FailureThreshold = spec.FailureThreshold
+	}
+	return probe
+}
+
+// httpProbeHandler returns a handler querying a health endpoint of the management interface
+func httpProbeHandler(path string) corev1.Handler {
+	return corev1.Handler{
+		HTTPGet: &corev1.HTTPGetAction{
+			Path:   path,
+			Port:   intstr.FromInt(management.DefaultPort),
+			Scheme: corev1.URISchemeHTTP,
+		},
+	}
+}
+
+// execProbeHandler returns a handler running a shell command in the container
+func execProbeHandler(command string) corev1.Handler {
+	return corev1.Handler{
+		Exec: &corev1.ExecAction{
+			Command: []string{"/bin/sh", "-c", command},
+		},
+	}
+}
@@ -0,0 +1,59 @@
+package wildfly
+
+import (
+	"testing"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+)
+
+func TestAddProbes(t *testing.T) {
+	tests := []struct {
+		name          string
+		probes        *wildflyv1alpha1.WildflyProbes
+		wantHTTP      bool
+		wantExec      bool
+		wantLiveDelay int32
+	}{
+		{"default", nil, true, false, livenessInitialDelayDefault},
+		{"HTTP", &wildflyv1alpha1.WildflyProbes{Handler: wildflyv1alpha1.ProbeHTTP}, true, false, livenessInitialDelayDefault},
+		{"empty handler", &wildflyv1alpha1.WildflyProbes{StartupTimeoutSeconds: 120}, true, false, 120},
+		{"Exec", &wildflyv1alpha1.WildflyProbes{Handler: wildflyv1alpha1.ProbeExec}, false, true, livenessInitialDelayDefault},
+		{"None", &wildflyv1alpha1.WildflyProbes{Handler: wildflyv1alpha1.ProbeNone}, false, false, 0},
+	}
+	for _, tt := range tests {
+		cr := &wildflyv1alpha1.Wildfly{}
+		cr.Spec.Probes = tt.probes
+		template := newTemplate("wildfly:17")
+		r := &ReconcileWildfly{}
+		r.addProbes(cr, template)
+
+		if got := httpProbesEnabled(cr); got != tt.wantHTTP {
+			t.Errorf("%s: got HTTP probes enabled %v, want %v", tt.name, got, tt.wantHTTP)
+		}
+		container := template.Spec.Containers[0]
+		if !tt.wantHTTP && !tt.wantExec {
+			if container.LivenessProbe != nil || container.ReadinessProbe != nil {
+				t.Errorf("%s: got probes %v and %v, want none", tt.name, container.LivenessProbe, container.ReadinessProbe)
+			}
+			continue
+		}
+		if container.LivenessProbe == nil || container.ReadinessProbe == nil {
+			t.Errorf("%s: got probes %v and %v, want both", tt.name, container.LivenessProbe, container.ReadinessProbe)
+			continue
+		}
+		if tt.wantHTTP {
+			if get := container.LivenessProbe.HTTPGet; get == nil || get.Path != livenessPath {
+				t.Errorf("%s: got liveness handler %+v, want GET %s", tt.name, container.LivenessProbe.Handler, livenessPath)
+			}
+			if get := container.ReadinessProbe.HTTPGet; get == nil || get.Path != readinessPath {
+				t.Errorf("%s: got readiness handler %+v, want GET %s", tt.name, container.ReadinessProbe.Handler, readinessPath)
+			}
+		}
+		if tt.wantExec && (container.LivenessProbe.Exec == nil || container.ReadinessProbe.Exec == nil) {
+			t.Errorf("%s: got handlers %+v and %+v, want exec", tt.name, container.LivenessProbe.Handler, container.ReadinessProbe.Handler)
+		}
+		if container.LivenessProbe.InitialDelaySeconds != tt.wantLiveDelay {
+			t.Errorf("%s: got liveness delay %d, want %d", tt.name, container.LivenessProbe.InitialDelaySeconds, tt.wantLiveDelay)
+		}
+	}
+}
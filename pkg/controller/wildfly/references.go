@@ -0,0 +1,88 @@
+package wildfly
+
+import (
+	"context"
+	"log"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// configMapReferences returns the names of the ConfigMaps a Wildfly depends on
+func configMapReferences(cr *wildflyv1alpha1.Wildfly) []string {
+	refs := []string{}
+	if cr.Spec.Config != nil && cr.Spec.Config.ConfigMap != "" {
+		refs = append(refs, cr.Spec.Config.ConfigMap)
+	}
+	for _, app := range cr.Spec.Applications {
+		if app.ConfigMap != "" {
+			refs = append(refs, app.ConfigMap)
+		}
+	}
+	refs = append(refs, cr.Spec.CliScripts...)
+	for _, source := range cr.Spec.EnvFrom {
+		if source.ConfigMapRef != nil {
+			refs = append(refs, source.ConfigMapRef.Name)
+		}
+	}
+	return refs
+}
+
+// secretReferences returns the names of the Secrets a Wildfly depends on
+func secretReferences(cr *wildflyv1alpha1.Wildfly) []string {
+	refs := []string{}
+	if cr.Spec.Expose != nil && cr.Spec.Expose.TLSSecret != "" {
+		refs = append(refs, cr.Spec.Expose.TLSSecret)
+	}
+	if httpsEnabled(cr) {
+		refs = append(refs, cr.Spec.HTTPS.Secret)
+	}
+	if managementEnabled(cr) {
+		refs = append(refs, cr.Spec.Management.CredentialsSecret)
+	}
+	for _, app := range cr.Spec.Applications {
+		if app.Secret != "" {
+			refs = append(refs, app.Secret)
+		}
+	}
+	for _, source := range cr.Spec.EnvFrom {
+		if source.SecretRef != nil {
+			refs = append(refs, source.SecretRef.Name)
+		}
+	}
+	for _, ds := range cr.Spec.Datasources {
+		if ds.CredentialsSecret != "" {
+			refs = append(refs, ds.CredentialsSecret)
+		}
+	}
+	return refs
+}
+
+// referenceMapper returns a Mapper that enqueues every Wildfly in the namespace of the
+// event object which references it by name, according to the refs function.
+func referenceMapper(c client.Client, refs func(*wildflyv1alpha1.Wildfly) []string) handler.Mapper {
+	return handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+		wildflyList := &wildflyv1alpha1.WildflyList{}
+		err := c.List(context.TODO(), client.InNamespace(obj.Meta.GetNamespace()), wildflyList)
+		if err != nil {
+			log.Printf("Failed to list Wildfly resources: %v\n", err)
+			return nil
+		}
+		requests := []reconcile.Request{}
+		for i := range wildflyList.Items {
+			for _, name := range refs(&wildflyList.Items[i]) {
+				if name == obj.Meta.GetName() {
+					requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+						Name:      wildflyList.Items[i].Name,
+						Namespace: wildflyList.Items[i].Namespace,
+					}})
+					break
+				}
+			}
+		}
+		return requests
+	})
+}
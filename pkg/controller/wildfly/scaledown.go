@@ -0,0 +1,408 @@
+package wildfly
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+	"github.com/giannisalinetti/wildfly-operator/pkg/controller/servers"
+	"github.com/giannisalinetti/wildfly-operator/pkg/management"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// scaleDownAnnotation marks the pods being drained before a scale down
+	scaleDownAnnotation = annotationPrefix + "scale-down"
+	// drainedAnnotation marks the data volumes whose transaction log was drained
+	drainedAnnotation = annotationPrefix + "drained"
+	// recoveryLabel identifies the recovery pods started for a Wildfly
+	recoveryLabel = annotationPrefix + "recovery"
+
+	// scaleDownPollInterval is how often the draining of the transaction log is checked
+	scaleDownPollInterval = 10 * time.Second
+)
+
+// Draining states of a server
+const (
+	drainSuspending  = "Suspending"
+	drainDraining    = "Draining"
+	drainDrained     = "Drained"
+	drainError       = "Error"
+	drainUnreachable = "Unreachable"
+	drainRecovering  = "Recovering"
+)
+
+// draining reports whether a scale down or a recovery is in progress for the Wildfly
+func draining(cr *wildflyv1alpha1.Wildfly) bool {
+	return cr.Status.ScaleDown != nil || len(cr.Status.Recoveries) > 0
+}
+
+// drainScaleDown runs the scale down protocol of the StatefulSet and returns the number of
+// replicas to apply. The pods with the highest ordinals are marked and suspended through the
+// management interface, and the replicas are lowered only when their transaction log is empty.
+// Pods that are not running cannot be drained: their data volume is left to the recovery.
+// The progress is recorded in the status of the Wildfly.
+func (r *ReconcileWildfly) drainScaleDown(cr *wildflyv1alpha1.Wildfly, ss *appsv1.StatefulSet) (int32, error) {
+	current := *ss.Spec.Replicas
+	desired := desiredReplicas(cr)
+	if !managementEnabled(cr) {
+		log.Printf("Management is not configured for Wildfly %s/%s, scaling down without draining\n", cr.Namespace, cr.Name)
+		cr.Status.ScaleDown = nil
+		return desired, nil
+	}
+
+	pods, err := r.listPods(cr)
+	if err != nil {
+		return current, err
+	}
+	clients, err := servers.NewClients(r.client, cr, pods)
+	if err != nil {
+		return current, err
+	}
+	podsByName := map[string]*corev1.Pod{}
+	for i := range pods {
+		podsByName[pods[i].Name] = &pods[i]
+	}
+
+	progress := &wildflyv1alpha1.WildflyScaleDown{From: current, To: desired}
+	done := true
+	for ordinal := desired; ordinal < current; ordinal++ {
+		name := fmt.Sprintf("%s-%d", ss.Name, ordinal)
+		drain := r.drainPod(name, podsByName[name], clients[name])
+		progress.Pods = append(progress.Pods, drain)
+		if drain.State != drainDrained && drain.State != drainUnreachable {
+			done = false
+		}
+	}
+
+	if !done {
+		log.Printf("Waiting for the transaction log of Wildfly %s/%s to drain before scaling down\n", cr.Namespace, cr.Name)
+		cr.Status.ScaleDown = progress
+		return current, nil
+	}
+
+	// The data volumes of the drained pods do not need any recovery
+	if cr.Spec.Storage != nil && cr.Spec.Storage.Data != nil {
+		for _, drain := range progress.Pods {
+			if drain.State == drainDrained {
+				err = r.setClaimDrained(cr, dataVolumeName+"-"+drain.Pod, true)
+				if err != nil {
+					return current, err
+				}
+			}
+		}
+	}
+	cr.Status.ScaleDown = nil
+	return desired, nil
+}
+
+// drainPod marks and suspends the server running in a pod, and reports whether its
+// transaction log is drained
+func (r *ReconcileWildfly) drainPod(name string, pod *corev1.Pod, c *management.Client) wildflyv1alpha1.WildflyDrain {
+	drain := wildflyv1alpha1.WildflyDrain{Pod: name}
+	if pod == nil || c == nil {
+		drain.State = drainUnreachable
+		drain.Message = "pod is not running, its transaction log is left to the recovery"
+		return drain
+	}
+
+	if pod.Annotations[scaleDownAnnotation] == "" {
+		if pod.Annotations == nil {
+			pod.Annotations = map[string]string{}
+		}
+		pod.Annotations[scaleDownAnnotation] = "draining"
+		err := r.client.Update(context.TODO(), pod)
+		if err != nil {
+			drain.State = drainError
+			drain.Message = err.Error()
+			return drain
+		}
+	}
+
+	return checkDrained(drain, c, true)
+}
+
+// resumeDrainingPods resumes the servers suspended by a scale down that was cancelled, i.e.
+// the pods marked for draining whose ordinal is kept by the requested replicas.
+func (r *ReconcileWildfly) resumeDrainingPods(cr *wildflyv1alpha1.Wildfly, replicas int32) error {
+	if !managementEnabled(cr) {
+		return nil
+	}
+	pods, err := r.listPods(cr)
+	if err != nil {
+		return err
+	}
+	var marked []corev1.Pod
+	for _, pod := range pods {
+		if pod.Annotations[scaleDownAnnotation] == "" {
+			continue
+		}
+		ordinal, err := strconv.Atoi(strings.TrimPrefix(pod.Name, cr.Name+"-"))
+		if err == nil && int32(ordinal) < replicas {
+			marked = append(marked, pod)
+		}
+	}
+	if len(marked) == 0 {
+		return nil
+	}
+
+	clients, err := servers.NewClients(r.client, cr, marked)
+	if err != nil {
+		return err
+	}
+	for i := range marked {
+		pod := &marked[i]
+		if c, ok := clients[pod.Name]; ok {
+			log.Printf("Resuming server %s/%s, the scale down was cancelled\n", pod.Namespace, pod.Name)
+			err = c.Resume()
+			if err != nil {
+				return err
+			}
+		}
+		delete(pod.Annotations, scaleDownAnnotation)
+		err = r.client.Update(context.TODO(), pod)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkDrained fills the draining state of a server from its suspend state and the content
+// of its transaction log. The server is suspended first when suspend is true.
+func checkDrained(drain wildflyv1alpha1.WildflyDrain, c *management.Client, suspend bool) wildflyv1alpha1.WildflyDrain {
+	if suspend {
+		state, err := c.SuspendState()
+		if err != nil {
+			drain.State = drainError
+			drain.Message = err.Error()
+			return drain
+		}
+		if state == management.SuspendStateRunning {
+			log.Printf("Suspending server %s before scale down\n", drain.Pod)
+			err = c.Suspend(0)
+			if err != nil {
+				drain.State = drainError
+				drain.Message = err.Error()
+				return drain
+			}
+			state = management.SuspendStateSuspending
+		}
+		if state != management.SuspendStateSuspended {
+			drain.State = drainSuspending
+			return drain
+		}
+	}
+
+	transactions, err := c.Transactions()
+	if err != nil {
+		drain.State = drainError
+		drain.Message = err.Error()
+		return drain
+	}
+	drain.Transactions = int32(len(transactions))
+	if len(transactions) > 0 {
+		drain.State = drainDraining
+		drain.Message = "waiting for the transactions to be recovered: " + strings.Join(transactions, ", ")
+		return drain
+	}
+	drain.State = drainDrained
+	return drain
+}
+
+// reconcileRecovery starts a recovery pod for each orphaned data volume of the StatefulSet
+// whose transaction log was not drained, i.e. the volumes of ordinals above the replicas left
+// by pods that were not running during the scale down. The server of the recovery pod
+// completes the transactions, then the volume is marked as drained and the pod is deleted.
+func (r *ReconcileWildfly) reconcileRecovery(cr *wildflyv1alpha1.Wildfly, ss *appsv1.StatefulSet) error {
+	cr.Status.Recoveries = nil
+	if cr.Spec.Storage == nil || cr.Spec.Storage.Data == nil || !managementEnabled(cr) || cr.Status.ScaleDown != nil {
+		return nil
+	}
+
+	claimList := &corev1.PersistentVolumeClaimList{}
+	err := r.client.List(context.TODO(), client.InNamespace(cr.Namespace).MatchingLabels(map[string]string{"app": cr.Name}), claimList)
+	if err != nil {
+		return err
+	}
+
+	replicas := *ss.Spec.Replicas
+	prefix := dataVolumeName + "-" + ss.Name + "-"
+	for i := range claimList.Items {
+		claim := &claimList.Items[i]
+		if !strings.HasPrefix(claim.Name, prefix) {
+			continue
+		}
+		ordinal, err := strconv.Atoi(strings.TrimPrefix(claim.Name, prefix))
+		if err != nil {
+			continue
+		}
+
+		if int32(ordinal) < replicas {
+			// The volume belongs to a pod of the StatefulSet again, the drained mark is only
+			// removed once the pod runs in case the replicas read from the cache are stale
+			err = r.deleteRecoveryPod(cr, ordinal)
+			if err != nil {
+				return err
+			}
+			if claim.Annotations[drainedAnnotation] != "" {
+				pod := &corev1.Pod{}
+				err = r.client.Get(context.TODO(), types.NamespacedName{Name: fmt.Sprintf("%s-%d", ss.Name, ordinal), Namespace: cr.Namespace}, pod)
+				if err == nil && pod.Status.Phase == corev1.PodRunning {
+					err = r.setClaimDrained(cr, claim.Name, false)
+				}
+				if err != nil && !errors.IsNotFound(err) {
+					return err
+				}
+			}
+			continue
+		}
+		if claim.Annotations[drainedAnnotation] != "" {
+			continue
+		}
+
+		drain, err := r.recover(cr, ss, claim, ordinal)
+		if err != nil {
+			return err
+		}
+		if drain.State != drainDrained {
+			cr.Status.Recoveries = append(cr.Status.Recoveries, drain)
+		}
+	}
+	return nil
+}
+
+// recover runs the recovery pod for an orphaned data volume and checks its transaction log
+func (r *ReconcileWildfly) recover(cr *wildflyv1alpha1.Wildfly, ss *appsv1.StatefulSet, claim *corev1.PersistentVolumeClaim,
+	ordinal int) (wildflyv1alpha1.WildflyDrain, error) {
+	name := recoveryPodName(cr, ordinal)
+	drain := wildflyv1alpha1.WildflyDrain{Pod: name, State: drainRecovering}
+
+	pod := &corev1.Pod{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: cr.Namespace}, pod)
+	if err != nil && errors.IsNotFound(err) {
+		pod = r.newRecoveryPod(cr, ss, claim.Name, ordinal)
+		log.Printf("Creating recovery pod %s/%s for volume %s\n", pod.Namespace, pod.Name, claim.Name)
+		return drain, r.client.Create(context.TODO(), pod)
+	} else if err != nil {
+		return drain, err
+	}
+
+	clients, err := servers.NewClients(r.client, cr, []corev1.Pod{*pod})
+	if err != nil {
+		return drain, err
+	}
+	c, ok := clients[name]
+	if !ok {
+		drain.Message = "waiting for the recovery pod to run"
+		return drain, nil
+	}
+	drain = checkDrained(drain, c, false)
+	if drain.State != drainDrained {
+		if drain.State == drainDraining {
+			drain.State = drainRecovering
+		}
+		return drain, nil
+	}
+
+	log.Printf("Transaction log of volume %s drained, deleting recovery pod %s/%s\n", claim.Name, pod.Namespace, pod.Name)
+	err = r.setClaimDrained(cr, claim.Name, true)
+	if err != nil {
+		return drain, err
+	}
+	return drain, r.client.Delete(context.TODO(), pod)
+}
+
+// recoveryPodName returns the name of the recovery pod for a StatefulSet ordinal
+func recoveryPodName(cr *wildflyv1alpha1.Wildfly, ordinal int) string {
+	return fmt.Sprintf("%s-recovery-%d", cr.Name, ordinal)
+}
+
+// newRecoveryPod returns a pod running the server of the StatefulSet against an orphaned data
+// volume. The pod is not labeled as part of the Wildfly, so it never receives requests.
+func (r *ReconcileWildfly) newRecoveryPod(cr *wildflyv1alpha1.Wildfly, ss *appsv1.StatefulSet, claimName string, ordinal int) *corev1.Pod {
+	spec := ss.Spec.Template.Spec.DeepCopy()
+	spec.Volumes = append(spec.Volumes, corev1.Volume{
+		Name: dataVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claimName},
+		},
+	})
+	// The other volumes of the servers are not needed to recover the transactions
+	for _, claim := range ss.Spec.VolumeClaimTemplates {
+		if claim.Name == dataVolumeName {
+			continue
+		}
+		spec.Volumes = append(spec.Volumes, corev1.Volume{
+			Name: claim.Name,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		})
+	}
+	pod := &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Pod",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      recoveryPodName(cr, ordinal),
+			Namespace: cr.Namespace,
+			Labels: map[string]string{
+				recoveryLabel: cr.Name,
+			},
+		},
+		Spec: *spec,
+	}
+	controllerutil.SetControllerReference(cr, pod, r.scheme)
+	return pod
+}
+
+// deleteRecoveryPod deletes the recovery pod of an ordinal if it exists
+func (r *ReconcileWildfly) deleteRecoveryPod(cr *wildflyv1alpha1.Wildfly, ordinal int) error {
+	pod := &corev1.Pod{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: recoveryPodName(cr, ordinal), Namespace: cr.Namespace}, pod)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	log.Printf("Deleting recovery pod %s/%s, its volume is used by the StatefulSet again\n", pod.Namespace, pod.Name)
+	return r.client.Delete(context.TODO(), pod)
+}
+
+// setClaimDrained adds or removes the drained annotation of a data volume claim
+func (r *ReconcileWildfly) setClaimDrained(cr *wildflyv1alpha1.Wildfly, claimName string, drained bool) error {
+	claim := &corev1.PersistentVolumeClaim{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: claimName, Namespace: cr.Namespace}, claim)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	_, found := claim.Annotations[drainedAnnotation]
+	if found == drained {
+		return nil
+	}
+	if drained {
+		if claim.Annotations == nil {
+			claim.Annotations = map[string]string{}
+		}
+		claim.Annotations[drainedAnnotation] = "true"
+	} else {
+		delete(claim.Annotations, drainedAnnotation)
+	}
+	return r.client.Update(context.TODO(), claim)
+}
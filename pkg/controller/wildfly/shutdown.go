@@ -0,0 +1,34 @@
+package wildfly
+
+import (
+	"strconv"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// shutdownMarginSeconds is added to the suspend timeout in the termination grace period, to
+// leave the time to start jboss-cli.sh and to stop the server after the suspension
+const shutdownMarginSeconds = int64(15)
+
+// addGracefulShutdown suspends the server in a preStop hook, so that the active requests and
+// EJB invocations can complete before the container receives SIGTERM. The termination grace
+// period always covers the suspend timeout; it is set to the API server default otherwise.
+func (r *ReconcileWildfly) addGracefulShutdown(cr *wildflyv1alpha1.Wildfly, template *corev1.PodTemplateSpec) {
+	gracePeriod := int64(corev1.DefaultTerminationGracePeriodSeconds)
+	template.Spec.TerminationGracePeriodSeconds = &gracePeriod
+	if cr.Spec.SuspendTimeoutSeconds <= 0 {
+		return
+	}
+
+	timeout := int64(cr.Spec.SuspendTimeoutSeconds)
+	gracePeriod = timeout + shutdownMarginSeconds
+	container := &template.Spec.Containers[0]
+	container.Lifecycle = &corev1.Lifecycle{
+		PreStop: &corev1.Handler{
+			Exec: &corev1.ExecAction{
+				Command: []string{jbossCliPath, "--connect", "--commands=:suspend(timeout=" + strconv.FormatInt(timeout, 10) + ")"},
+			},
+		},
+	}
+}
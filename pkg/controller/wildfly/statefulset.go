@@ -0,0 +1,279 @@
+package wildfly
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// statefulSetMode reports whether the servers run in a StatefulSet
+func statefulSetMode(cr *wildflyv1alpha1.Wildfly) bool {
+	return cr.Spec.Mode == wildflyv1alpha1.ModeStatefulSet
+}
+
+// headlessServiceName returns the name of the headless Service governing the StatefulSet
+func headlessServiceName(cr *wildflyv1alpha1.Wildfly) string {
+	return cr.Name + "-headless"
+}
+
+// reconcileStatefulSet creates the StatefulSet of the Wildfly or converges the fields owned by
+// the operator, and returns it. The request must be requeued when the StatefulSet was changed.
+func (r *ReconcileWildfly) reconcileStatefulSet(cr *wildflyv1alpha1.Wildfly) (*appsv1.StatefulSet, bool, error) {
+	desiredSS, err := r.newWildflyStatefulSet(cr)
+	if err != nil {
+		log.Printf("Failed to define desired Wildfly StatefulSet: %v\n", err)
+		return nil, false, err
+	}
+
+	foundSS := &appsv1.StatefulSet{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}, foundSS)
+	if err != nil && errors.IsNotFound(err) {
+		log.Printf("Creating a new Wildfly StatefulSet: %s/%s\n", desiredSS.Namespace, desiredSS.Name)
+		err = r.client.Create(context.TODO(), desiredSS)
+		if err != nil {
+			log.Printf("Failed to create new Wildfly StatefulSet: %v\n", err)
+			return nil, false, err
+		}
+		return desiredSS, true, nil
+	} else if err != nil {
+		log.Printf("Failed to get StatefulSet: %v\n", err)
+		return nil, false, err
+	}
+
+	// The claim templates are immutable: the StatefulSet is deleted without its pods when
+	// volumes are added or removed, and created again with the new templates. The pods are
+	// adopted and rolled by the new StatefulSet.
+	if foundSS.DeletionTimestamp != nil {
+		return foundSS, true, nil
+	}
+	if !reflect.DeepEqual(claimTemplateNames(foundSS), claimTemplateNames(desiredSS)) {
+		log.Printf("Deleting Wildfly StatefulSet %s/%s to change its volumes\n", foundSS.Namespace, foundSS.Name)
+		err = r.client.Delete(context.TODO(), foundSS, client.PropagationPolicy(metav1.DeletePropagationOrphan))
+		if err != nil {
+			log.Printf("Failed to delete Wildfly StatefulSet: %v\n", err)
+			return nil, false, err
+		}
+		return foundSS, true, nil
+	}
+
+	// Only the replicas and the pod template of a StatefulSet can be updated. Scaling down
+	// goes through the draining of the servers.
+	changed := false
+	replicas := *desiredSS.Spec.Replicas
+	if foundSS.Spec.Replicas != nil && replicas < *foundSS.Spec.Replicas {
+		replicas, err = r.drainScaleDown(cr, foundSS)
+		if err != nil {
+			log.Printf("Failed to drain Wildfly StatefulSet: %v\n", err)
+			return nil, false, err
+		}
+	} else {
+		cr.Status.ScaleDown = nil
+		err = r.resumeDrainingPods(cr, replicas)
+		if err != nil {
+			log.Printf("Failed to resume Wildfly pods: %v\n", err)
+			return nil, false, err
+		}
+	}
+	if foundSS.Spec.Replicas == nil || *foundSS.Spec.Replicas != replicas {
+		foundSS.Spec.Replicas = &replicas
+		changed = true
+	}
+	if r.mergePodTemplate(cr, &foundSS.Spec.Template, &desiredSS.Spec.Template) {
+		changed = true
+	}
+	if changed {
+		log.Printf("Updating Wildfly StatefulSet: %s/%s\n", foundSS.Namespace, foundSS.Name)
+		err = r.client.Update(context.TODO(), foundSS)
+		if err != nil {
+			log.Printf("Failed to update Wildfly StatefulSet: %v\n", err)
+			return nil, false, err
+		}
+		return foundSS, true, nil
+	}
+
+	// Recover the transaction log of the volumes orphaned by pods that could not be drained
+	err = r.reconcileRecovery(cr, foundSS)
+	if err != nil {
+		log.Printf("Failed to recover Wildfly transaction logs: %v\n", err)
+		return nil, false, err
+	}
+	return foundSS, false, nil
+}
+
+// newWildflyStatefulSet manages the creation of a wildfly StatefulSet
+func (r *ReconcileWildfly) newWildflyStatefulSet(cr *wildflyv1alpha1.Wildfly) (*appsv1.StatefulSet, error) {
+	replicas := desiredReplicas(cr)
+	labels := map[string]string{
+		"app": cr.Name,
+	}
+
+	template, err := r.newPodTemplate(cr)
+	if err != nil {
+		return nil, err
+	}
+
+	ss := &appsv1.StatefulSet{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       "StatefulSet",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cr.Name,
+			Namespace: cr.Namespace,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:    &replicas,
+			ServiceName: headlessServiceName(cr),
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: template,
+		},
+	}
+
+	// Each pod gets its own volumes for standalone/data, where the transaction log is stored,
+	// and standalone/log
+	for _, v := range storageVolumes(cr) {
+		claim, err := newVolumeClaim(v.name, v.claim)
+		if err != nil {
+			return nil, err
+		}
+		ss.Spec.VolumeClaimTemplates = append(ss.Spec.VolumeClaimTemplates, claim)
+	}
+
+	controllerutil.SetControllerReference(cr, ss, r.scheme)
+	return ss, nil
+}
+
+// newVolumeClaim returns a PersistentVolumeClaim defined by the custom resource
+func newVolumeClaim(name string, spec *wildflyv1alpha1.WildflyVolumeClaim) (corev1.PersistentVolumeClaim, error) {
+	size, err := resource.ParseQuantity(spec.Size)
+	if err != nil {
+		return corev1.PersistentVolumeClaim{}, fmt.Errorf("invalid size %q for volume %s: %v", spec.Size, name, err)
+	}
+	accessModes := spec.AccessModes
+	if len(accessModes) == 0 {
+		accessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}
+	}
+	return corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      accessModes,
+			StorageClassName: spec.StorageClassName,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: size,
+				},
+			},
+		},
+	}, nil
+}
+
+// newWildflyHeadlessService returns the headless Service giving a stable network identity to
+// the pods of the StatefulSet. Not ready addresses are published so that the pods can resolve
+// each other while booting.
+func (r *ReconcileWildfly) newWildflyHeadlessService(cr *wildflyv1alpha1.Wildfly) *corev1.Service {
+	labels := map[string]string{
+		"app": cr.Name,
+	}
+	svc := &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Service",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      headlessServiceName(cr),
+			Namespace: cr.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:                     corev1.ServiceTypeClusterIP,
+			ClusterIP:                corev1.ClusterIPNone,
+			Selector:                 labels,
+			Ports:                    r.loadServicePorts(cr),
+			PublishNotReadyAddresses: true,
+		},
+	}
+	controllerutil.SetControllerReference(cr, svc, r.scheme)
+	return svc
+}
+
+// headlessServiceNeeded reports whether the Wildfly needs a headless Service, either to govern
+// the StatefulSet or to let the cluster members resolve each other with DNS_PING
+func headlessServiceNeeded(cr *wildflyv1alpha1.Wildfly) bool {
+	return statefulSetMode(cr) || dnsPingEnabled(cr)
+}
+
+// reconcileHeadlessService creates or updates the headless Service when it is needed, and
+// removes it otherwise.
+func (r *ReconcileWildfly) reconcileHeadlessService(cr *wildflyv1alpha1.Wildfly) error {
+	if headlessServiceNeeded(cr) {
+		_, _, err := r.reconcileService(r.newWildflyHeadlessService(cr))
+		return err
+	}
+
+	found := &corev1.Service{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: headlessServiceName(cr), Namespace: cr.Namespace}, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if !metav1.IsControlledBy(found, cr) {
+		return nil
+	}
+	log.Printf("Deleting Wildfly headless Service: %s/%s\n", found.Namespace, found.Name)
+	return r.client.Delete(context.TODO(), found)
+}
+
+// removeStaleWorkload deletes the Deployment or StatefulSet left over after the mode of the
+// Wildfly was switched. The old workload keeps serving requests until all the replicas of the
+// current one are ready, so that the migration does not interrupt the service.
+func (r *ReconcileWildfly) removeStaleWorkload(cr *wildflyv1alpha1.Wildfly, workload runtime.Object) error {
+	var stale runtime.Object
+	var ready bool
+	switch w := workload.(type) {
+	case *appsv1.StatefulSet:
+		stale = &appsv1.Deployment{}
+		ready = w.Status.ReadyReplicas >= desiredReplicas(cr)
+	case *appsv1.Deployment:
+		stale = &appsv1.StatefulSet{}
+		ready = w.Status.ReadyReplicas >= desiredReplicas(cr)
+	default:
+		return nil
+	}
+
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}, stale)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	staleMeta, ok := stale.(metav1.Object)
+	if !ok || !metav1.IsControlledBy(staleMeta, cr) {
+		return nil
+	}
+	if !ready {
+		log.Printf("Waiting for the Wildfly %s/%s to be ready before removing the workload of the previous mode\n",
+			cr.Namespace, cr.Name)
+		return nil
+	}
+	log.Printf("Deleting the workload of the previous mode for Wildfly %s/%s\n", cr.Namespace, cr.Name)
+	return r.client.Delete(context.TODO(), stale)
+}
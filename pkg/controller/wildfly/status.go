@@ -0,0 +1,199 @@
+package wildfly
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// updateStatus computes the observed state of the Wildfly from the owned Deployment or
+// StatefulSet, its pods and the Service, and writes it through the status client when it
+// differs from the previous one. The status of cr may already hold progress recorded during
+// the reconcile, e.g. by the scale down.
+func (r *ReconcileWildfly) updateStatus(cr *wildflyv1alpha1.Wildfly, previous *wildflyv1alpha1.WildflyStatus,
+	workload runtime.Object, svc *corev1.Service) error {
+	status := cr.Status.DeepCopy()
+
+	pods, err := r.listPods(cr)
+	if err != nil {
+		log.Printf("Failed to list pods for Wildfly %s/%s: %v\n", cr.Namespace, cr.Name, err)
+		return err
+	}
+	status.Pods = nil
+	for _, pod := range pods {
+		status.Pods = append(status.Pods, pod.Name)
+	}
+
+	var template *corev1.PodTemplateSpec
+	var degraded corev1.ConditionStatus
+	var degradedReason, degradedMessage string
+	switch w := workload.(type) {
+	case *appsv1.Deployment:
+		status.Mode = wildflyv1alpha1.ModeDeployment
+		status.Replicas = w.Status.Replicas
+		status.ReadyReplicas = w.Status.ReadyReplicas
+		template = &w.Spec.Template
+		degraded, degradedReason, degradedMessage = r.setDeploymentConditions(status, w)
+	case *appsv1.StatefulSet:
+		status.Mode = wildflyv1alpha1.ModeStatefulSet
+		status.Replicas = w.Status.Replicas
+		status.ReadyReplicas = w.Status.ReadyReplicas
+		template = &w.Spec.Template
+		degraded, degradedReason, degradedMessage = r.setStatefulSetConditions(status, w)
+	default:
+		return fmt.Errorf("unexpected workload type %T", workload)
+	}
+
+	// A failed bootstrap takes precedence over the state of the workload, the condition is
+	// set once so that its transition time only moves when its status changes
+	if message, failed := bootstrapFailure(pods); failed {
+		degraded, degradedReason, degradedMessage = corev1.ConditionTrue, bootstrapFailedReason, message
+	}
+	setCondition(status, wildflyv1alpha1.WildflyDegraded, degraded, degradedReason, degradedMessage)
+
+	if c := findContainer(template.Spec.Containers, containerNameString); c != nil {
+		status.Image = c.Image
+	}
+
+	status.Servers = nil
+	if managementEnabled(cr) {
+		status.Servers = r.readServers(cr, pods)
+	}
+	status.Applications = r.readApplications(cr, pods)
+
+	status.ClusterIP = svc.Spec.ClusterIP
+	status.NodePorts = nil
+	for _, p := range svc.Spec.Ports {
+		if p.NodePort != 0 {
+			status.NodePorts = append(status.NodePorts, wildflyv1alpha1.WildflyNodePort{Port: p.Port, NodePort: p.NodePort})
+		}
+	}
+
+	if reflect.DeepEqual(previous, status) {
+		return nil
+	}
+	cr.Status = *status
+	err = r.client.Status().Update(context.TODO(), cr)
+	if err != nil {
+		log.Printf("Failed to update Wildfly status: %v\n", err)
+		return err
+	}
+	return nil
+}
+
+// listPods returns the pods labeled as part of the Wildfly, sorted by name
+func (r *ReconcileWildfly) listPods(cr *wildflyv1alpha1.Wildfly) ([]corev1.Pod, error) {
+	podList := &corev1.PodList{}
+	opts := client.InNamespace(cr.Namespace).MatchingLabels(map[string]string{"app": cr.Name})
+	err := r.client.List(context.TODO(), opts, podList)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(podList.Items, func(i, j int) bool {
+		return podList.Items[i].Name < podList.Items[j].Name
+	})
+	return podList.Items, nil
+}
+
+// setDeploymentConditions maps the conditions of the Deployment onto the Available and
+// Progressing conditions of the Wildfly, and returns the status, reason and message of the
+// Degraded condition derived from them.
+func (r *ReconcileWildfly) setDeploymentConditions(status *wildflyv1alpha1.WildflyStatus,
+	dep *appsv1.Deployment) (corev1.ConditionStatus, string, string) {
+	available := corev1.ConditionUnknown
+	availableReason, availableMessage := "", ""
+	progressing := corev1.ConditionUnknown
+	progressingReason, progressingMessage := "", ""
+	degraded := corev1.ConditionFalse
+	degradedReason, degradedMessage := "", ""
+
+	for _, c := range dep.Status.Conditions {
+		switch c.Type {
+		case appsv1.DeploymentAvailable:
+			available = c.Status
+			availableReason, availableMessage = c.Reason, c.Message
+		case appsv1.DeploymentProgressing:
+			progressing = c.Status
+			progressingReason, progressingMessage = c.Reason, c.Message
+			// The Deployment controller flips Progressing to False when the deadline is exceeded
+			if c.Status == corev1.ConditionFalse {
+				degraded = corev1.ConditionTrue
+				degradedReason, degradedMessage = c.Reason, c.Message
+			}
+		case appsv1.DeploymentReplicaFailure:
+			if c.Status == corev1.ConditionTrue {
+				degraded = corev1.ConditionTrue
+				degradedReason, degradedMessage = c.Reason, c.Message
+			}
+		}
+	}
+
+	setCondition(status, wildflyv1alpha1.WildflyAvailable, available, availableReason, availableMessage)
+	setCondition(status, wildflyv1alpha1.WildflyProgressing, progressing, progressingReason, progressingMessage)
+	return degraded, degradedReason, degradedMessage
+}
+
+// setStatefulSetConditions computes the Available and Progressing conditions of the Wildfly
+// from the replica counters of the StatefulSet, which does not report conditions, and returns
+// the status, reason and message of the Degraded condition.
+func (r *ReconcileWildfly) setStatefulSetConditions(status *wildflyv1alpha1.WildflyStatus,
+	ss *appsv1.StatefulSet) (corev1.ConditionStatus, string, string) {
+	desired := int32(1)
+	if ss.Spec.Replicas != nil {
+		desired = *ss.Spec.Replicas
+	}
+
+	if ss.Status.ReadyReplicas >= desired {
+		setCondition(status, wildflyv1alpha1.WildflyAvailable, corev1.ConditionTrue, "MinimumReplicasAvailable",
+			"StatefulSet has the desired number of ready replicas")
+	} else {
+		setCondition(status, wildflyv1alpha1.WildflyAvailable, corev1.ConditionFalse, "MinimumReplicasUnavailable",
+			fmt.Sprintf("StatefulSet has %d ready replicas out of %d", ss.Status.ReadyReplicas, desired))
+	}
+
+	if ss.Status.ObservedGeneration < ss.Generation || ss.Status.UpdatedReplicas < desired ||
+		ss.Status.CurrentRevision != ss.Status.UpdateRevision {
+		setCondition(status, wildflyv1alpha1.WildflyProgressing, corev1.ConditionTrue, "RollingUpdate",
+			"StatefulSet is rolling out revision "+ss.Status.UpdateRevision)
+	} else {
+		setCondition(status, wildflyv1alpha1.WildflyProgressing, corev1.ConditionTrue, "NewRevisionAvailable",
+			"StatefulSet revision "+ss.Status.CurrentRevision+" is available")
+	}
+
+	return corev1.ConditionFalse, "", ""
+}
+
+// setCondition adds or updates a condition in the status. The transition time is only
+// bumped when the condition status actually changes.
+func setCondition(status *wildflyv1alpha1.WildflyStatus, condType wildflyv1alpha1.WildflyConditionType,
+	condStatus corev1.ConditionStatus, reason, message string) {
+	for i := range status.Conditions {
+		c := &status.Conditions[i]
+		if c.Type != condType {
+			continue
+		}
+		if c.Status != condStatus {
+			c.Status = condStatus
+			c.LastTransitionTime = metav1.Now()
+		}
+		c.Reason = reason
+		c.Message = message
+		return
+	}
+	status.Conditions = append(status.Conditions, wildflyv1alpha1.WildflyCondition{
+		Type:               condType,
+		Status:             condStatus,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	})
+}
@@ -0,0 +1,278 @@
+package wildfly
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"strconv"
+	"strings"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	dataPath       = "/opt/jboss/wildfly/standalone/data"
+	dataVolumeName = volumePrefix + "data"
+	logsPath       = "/opt/jboss/wildfly/standalone/log"
+	logsVolumeName = volumePrefix + "logs"
+	podNameEnvVar  = "POD_NAME"
+	// jbossGroup is the group of the jboss user running the servers in the WildFly images
+	jbossGroup int64 = 1000
+)
+
+// storageVolume is a persistent volume of the servers mounted on one of their directories
+type storageVolume struct {
+	name      string
+	mountPath string
+	claim     *wildflyv1alpha1.WildflyVolumeClaim
+}
+
+// storageVolumes returns the persistent volumes defined in Spec.Storage
+func storageVolumes(cr *wildflyv1alpha1.Wildfly) []storageVolume {
+	var volumes []storageVolume
+	if cr.Spec.Storage == nil {
+		return volumes
+	}
+	if cr.Spec.Storage.Data != nil {
+		volumes = append(volumes, storageVolume{name: dataVolumeName, mountPath: dataPath, claim: cr.Spec.Storage.Data})
+	}
+	if cr.Spec.Storage.Logs != nil {
+		volumes = append(volumes, storageVolume{name: logsVolumeName, mountPath: logsPath, claim: cr.Spec.Storage.Logs})
+	}
+	return volumes
+}
+
+// sharedClaimName returns the name of the claim shared by the pods of the Deployment
+func sharedClaimName(cr *wildflyv1alpha1.Wildfly, volume string) string {
+	return cr.Name + "-" + strings.TrimPrefix(volume, volumePrefix)
+}
+
+// readWriteMany reports whether a claim can be mounted by pods running on several nodes
+func readWriteMany(claim *wildflyv1alpha1.WildflyVolumeClaim) bool {
+	for _, mode := range claim.AccessModes {
+		if mode == corev1.ReadWriteMany {
+			return true
+		}
+	}
+	return false
+}
+
+// validateStorage checks the volumes shared by several pods of the Deployment. The data
+// volume holds the transaction log and the journals of a single server and can never be
+// shared, the logs volume must be mountable on any node.
+func validateStorage(cr *wildflyv1alpha1.Wildfly) error {
+	if statefulSetMode(cr) || desiredReplicas(cr) <= 1 {
+		return nil
+	}
+	for _, v := range storageVolumes(cr) {
+		if v.name == dataVolumeName {
+			return fmt.Errorf("the data volume cannot be shared by the %d pods of a Deployment, each server needs its own transaction log and journals: use the StatefulSet mode",
+				desiredReplicas(cr))
+		}
+		if !readWriteMany(v.claim) {
+			return fmt.Errorf("volume %s is shared by %d pods in Deployment mode and needs the ReadWriteMany access mode, use the StatefulSet mode to give each pod its own volume",
+				strings.TrimPrefix(v.name, volumePrefix), desiredReplicas(cr))
+		}
+	}
+	return nil
+}
+
+// sharedLogs reports whether the logs volume is shared by the pods of the Deployment. Each
+// server then writes its logs in a directory named after its pod.
+func sharedLogs(cr *wildflyv1alpha1.Wildfly) bool {
+	return !statefulSetMode(cr) && cr.Spec.Storage != nil && cr.Spec.Storage.Logs != nil
+}
+
+// logDirFlags returns the flag moving the log directory of the server to the directory of its
+// pod in the shared logs volume
+func logDirFlags(cr *wildflyv1alpha1.Wildfly) []string {
+	if !sharedLogs(cr) {
+		return nil
+	}
+	return []string{"-Djboss.server.log.dir=" + logsPath + "/$(" + podNameEnvVar + ")"}
+}
+
+// deploymentStrategy returns the strategy of the Deployment. The old pods must release the
+// data volume, and the volumes which cannot be mounted on several nodes, before the new ones
+// start.
+func deploymentStrategy(cr *wildflyv1alpha1.Wildfly) appsv1.DeploymentStrategyType {
+	for _, v := range storageVolumes(cr) {
+		if v.name == dataVolumeName || !readWriteMany(v.claim) {
+			return appsv1.RecreateDeploymentStrategyType
+		}
+	}
+	return appsv1.RollingUpdateDeploymentStrategyType
+}
+
+// fsGroup returns the group owning the persistent volumes mounted in the pods, nil when the
+// pods have no volume or when the security context constraint assigns it on OpenShift
+func (r *ReconcileWildfly) fsGroup(cr *wildflyv1alpha1.Wildfly) *int64 {
+	if len(storageVolumes(cr)) == 0 {
+		return nil
+	}
+	if cr.Spec.Storage.FSGroup != nil {
+		group := *cr.Spec.Storage.FSGroup
+		return &group
+	}
+	if r.routeAvailable {
+		return nil
+	}
+	group := jbossGroup
+	return &group
+}
+
+// addStorage mounts the persistent volumes in the wildfly container. In StatefulSet mode the
+// volumes come from the claim templates, in Deployment mode from the shared claims. The pods
+// own the volumes through their file system group.
+func (r *ReconcileWildfly) addStorage(cr *wildflyv1alpha1.Wildfly, template *corev1.PodTemplateSpec) {
+	if group := r.fsGroup(cr); group != nil {
+		template.Spec.SecurityContext = &corev1.PodSecurityContext{FSGroup: group}
+	}
+	container := &template.Spec.Containers[0]
+	for _, v := range storageVolumes(cr) {
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      v.name,
+			MountPath: v.mountPath,
+		})
+		if statefulSetMode(cr) {
+			continue
+		}
+		template.Spec.Volumes = append(template.Spec.Volumes, corev1.Volume{
+			Name: v.name,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: sharedClaimName(cr, v.name)},
+			},
+		})
+	}
+}
+
+// mergeFSGroup copies the file system group of the desired pod template into the found one and
+// reports whether it changed. The other fields of the security context are left as they are.
+func mergeFSGroup(found, desired *corev1.PodTemplateSpec) bool {
+	var foundGroup, desiredGroup *int64
+	if found.Spec.SecurityContext != nil {
+		foundGroup = found.Spec.SecurityContext.FSGroup
+	}
+	if desired.Spec.SecurityContext != nil {
+		desiredGroup = desired.Spec.SecurityContext.FSGroup
+	}
+	if reflect.DeepEqual(foundGroup, desiredGroup) {
+		return false
+	}
+	if found.Spec.SecurityContext == nil {
+		found.Spec.SecurityContext = &corev1.PodSecurityContext{}
+	}
+	found.Spec.SecurityContext.FSGroup = desiredGroup
+	return true
+}
+
+// claimTemplateNames returns the names of the claim templates of a StatefulSet
+func claimTemplateNames(ss *appsv1.StatefulSet) []string {
+	names := []string{}
+	for _, claim := range ss.Spec.VolumeClaimTemplates {
+		names = append(names, claim.Name)
+	}
+	return names
+}
+
+// reconcileVolumeClaims creates the claims shared by the pods in Deployment mode and expands
+// the claims of the servers when the requested size grows. The claims are not owned by the
+// Wildfly: like the ones of a StatefulSet, they are kept when it is deleted.
+func (r *ReconcileWildfly) reconcileVolumeClaims(cr *wildflyv1alpha1.Wildfly) error {
+	volumes := storageVolumes(cr)
+	if len(volumes) == 0 {
+		return nil
+	}
+
+	if !statefulSetMode(cr) {
+		for _, v := range volumes {
+			claim := &corev1.PersistentVolumeClaim{}
+			err := r.client.Get(context.TODO(), types.NamespacedName{Name: sharedClaimName(cr, v.name), Namespace: cr.Namespace}, claim)
+			if err != nil && errors.IsNotFound(err) {
+				desired, err := newVolumeClaim(sharedClaimName(cr, v.name), v.claim)
+				if err != nil {
+					return err
+				}
+				desired.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "PersistentVolumeClaim"}
+				desired.Namespace = cr.Namespace
+				desired.Labels = map[string]string{"app": cr.Name}
+				log.Printf("Creating PersistentVolumeClaim: %s/%s\n", desired.Namespace, desired.Name)
+				err = r.client.Create(context.TODO(), &desired)
+				if err != nil {
+					log.Printf("Failed to create PersistentVolumeClaim: %v\n", err)
+					return err
+				}
+				continue
+			} else if err != nil {
+				log.Printf("Failed to get PersistentVolumeClaim: %v\n", err)
+				return err
+			}
+			err = r.expandVolumeClaim(claim, v.claim)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// The claims of the StatefulSet are named <template>-<statefulset>-<ordinal>
+	claimList := &corev1.PersistentVolumeClaimList{}
+	err := r.client.List(context.TODO(), client.InNamespace(cr.Namespace).MatchingLabels(map[string]string{"app": cr.Name}), claimList)
+	if err != nil {
+		return err
+	}
+	for i := range claimList.Items {
+		claim := &claimList.Items[i]
+		for _, v := range volumes {
+			prefix := v.name + "-" + cr.Name + "-"
+			if !strings.HasPrefix(claim.Name, prefix) {
+				continue
+			}
+			if _, err := strconv.Atoi(strings.TrimPrefix(claim.Name, prefix)); err != nil {
+				continue
+			}
+			err = r.expandVolumeClaim(claim, v.claim)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// expandVolumeClaim raises the requested size of a claim to the size of the spec. A claim is
+// never shrunk: a smaller size is reported as an error, like a rejected expansion, e.g.
+// because the storage class does not allow it.
+func (r *ReconcileWildfly) expandVolumeClaim(claim *corev1.PersistentVolumeClaim, spec *wildflyv1alpha1.WildflyVolumeClaim) error {
+	size, err := resource.ParseQuantity(spec.Size)
+	if err != nil {
+		return fmt.Errorf("invalid size %q for volume %s: %v", spec.Size, claim.Name, err)
+	}
+	current := claim.Spec.Resources.Requests[corev1.ResourceStorage]
+	switch size.Cmp(current) {
+	case 0:
+		return nil
+	case -1:
+		return fmt.Errorf("PersistentVolumeClaim %s/%s cannot be shrunk from %s to %s", claim.Namespace, claim.Name, current.String(), size.String())
+	}
+
+	if claim.Spec.Resources.Requests == nil {
+		claim.Spec.Resources.Requests = corev1.ResourceList{}
+	}
+	claim.Spec.Resources.Requests[corev1.ResourceStorage] = size
+	log.Printf("Expanding PersistentVolumeClaim %s/%s from %s to %s\n", claim.Namespace, claim.Name, current.String(), size.String())
+	err = r.client.Update(context.TODO(), claim)
+	if err != nil {
+		log.Printf("Failed to expand PersistentVolumeClaim %s/%s: %v\n", claim.Namespace, claim.Name, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package wildfly
+
+import (
+	"reflect"
+	"testing"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// newStorageWildfly returns a Wildfly with the given mode, size and volumes
+func newStorageWildfly(mode wildflyv1alpha1.WildflyMode, size int32, data, logs *wildflyv1alpha1.WildflyVolumeClaim) *wildflyv1alpha1.Wildfly {
+	cr := &wildflyv1alpha1.Wildfly{}
+	cr.Name = "example"
+	cr.Spec.Mode = mode
+	cr.Spec.Size = size
+	if data != nil || logs != nil {
+		cr.Spec.Storage = &wildflyv1alpha1.WildflyStorage{Data: data, Logs: logs}
+	}
+	return cr
+}
+
+var (
+	rwoClaim = &wildflyv1alpha1.WildflyVolumeClaim{Size: "1Gi"}
+	rwxClaim = &wildflyv1alpha1.WildflyVolumeClaim{Size: "1Gi", AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}}
+)
+
+func TestValidateStorage(t *testing.T) {
+	tests := []struct {
+		name    string
+		cr      *wildflyv1alpha1.Wildfly
+		wantErr bool
+	}{
+		{"no storage", newStorageWildfly(wildflyv1alpha1.ModeDeployment, 3, nil, nil), false},
+		{"single replica data", newStorageWildfly(wildflyv1alpha1.ModeDeployment, 1, rwoClaim, nil), false},
+		{"shared data", newStorageWildfly(wildflyv1alpha1.ModeDeployment, 2, rwoClaim, nil), true},
+		{"shared read write many data", newStorageWildfly(wildflyv1alpha1.ModeDeployment, 2, rwxClaim, nil), true},
+		{"shared read write once logs", newStorageWildfly(wildflyv1alpha1.ModeDeployment, 2, nil, rwoClaim), true},
+		{"shared read write many logs", newStorageWildfly(wildflyv1alpha1.ModeDeployment, 2, nil, rwxClaim), false},
+		{"stateful set", newStorageWildfly(wildflyv1alpha1.ModeStatefulSet, 3, rwoClaim, rwoClaim), false},
+	}
+	for _, tt := range tests {
+		err := validateStorage(tt.cr)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: got error %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestDeploymentStrategy(t *testing.T) {
+	tests := []struct {
+		name string
+		cr   *wildflyv1alpha1.Wildfly
+		want appsv1.DeploymentStrategyType
+	}{
+		{"no storage", newStorageWildfly(wildflyv1alpha1.ModeDeployment, 1, nil, nil), appsv1.RollingUpdateDeploymentStrategyType},
+		{"data", newStorageWildfly(wildflyv1alpha1.ModeDeployment, 1, rwxClaim, nil), appsv1.RecreateDeploymentStrategyType},
+		{"read write once logs", newStorageWildfly(wildflyv1alpha1.ModeDeployment, 1, nil, rwoClaim), appsv1.RecreateDeploymentStrategyType},
+		{"read write many logs", newStorageWildfly(wildflyv1alpha1.ModeDeployment, 2, nil, rwxClaim), appsv1.RollingUpdateDeploymentStrategyType},
+	}
+	for _, tt := range tests {
+		if got := deploymentStrategy(tt.cr); got != tt.want {
+			t.Errorf("%s: got strategy %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLogDirFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		cr   *wildflyv1alpha1.Wildfly
+		want []string
+	}{
+		{"no storage", newStorageWildfly(wildflyv1alpha1.ModeDeployment, 2, nil, nil), nil},
+		{"data only", newStorageWildfly(wildflyv1alpha1.ModeDeployment, 1, rwoClaim, nil), nil},
+		{"shared logs", newStorageWildfly(wildflyv1alpha1.ModeDeployment, 2, nil, rwxClaim),
+			[]string{"-Djboss.server.log.dir=" + logsPath + "/$(POD_NAME)"}},
+		{"stateful set logs", newStorageWildfly(wildflyv1alpha1.ModeStatefulSet, 2, nil, rwoClaim), nil},
+	}
+	for _, tt := range tests {
+		if got := logDirFlags(tt.cr); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got flags %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFSGroup(t *testing.T) {
+	defaultGroup := jbossGroup
+	group := int64(2000)
+	withGroup := newStorageWildfly(wildflyv1alpha1.ModeDeployment, 1, rwoClaim, nil)
+	withGroup.Spec.Storage.FSGroup = &group
+
+	tests := []struct {
+		name      string
+		cr        *wildflyv1alpha1.Wildfly
+		openShift bool
+		want      *int64
+	}{
+		{"no storage", newStorageWildfly(wildflyv1alpha1.ModeDeployment, 1, nil, nil), false, nil},
+		{"default", newStorageWildfly(wildflyv1alpha1.ModeDeployment, 1, rwoClaim, nil), false, &defaultGroup},
+		{"default on OpenShift", newStorageWildfly(wildflyv1alpha1.ModeDeployment, 1, rwoClaim, nil), true, nil},
+		{"set", withGroup, false, &group},
+		{"set on OpenShift", withGroup, true, &group},
+	}
+	for _, tt := range tests {
+		r := &ReconcileWildfly{routeAvailable: tt.openShift}
+		if got := r.fsGroup(tt.cr); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got group %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMergeFSGroup(t *testing.T) {
+	group := int64(1000)
+	other := int64(2000)
+	user := int64(1001)
+
+	tests := []struct {
+		name        string
+		found       *corev1.PodSecurityContext
+		desired     *corev1.PodSecurityContext
+		want        *corev1.PodSecurityContext
+		wantChanged bool
+	}{
+		{"none", nil, nil, nil, false},
+		{"defaulted empty context", &corev1.PodSecurityContext{}, nil, &corev1.PodSecurityContext{}, false},
+		{"added", nil, &corev1.PodSecurityContext{FSGroup: &group}, &corev1.PodSecurityContext{FSGroup: &group}, true},
+		{"unchanged", &corev1.PodSecurityContext{FSGroup: &group}, &corev1.PodSecurityContext{FSGroup: &group},
+			&corev1.PodSecurityContext{FSGroup: &group}, false},
+		{"changed", &corev1.PodSecurityContext{FSGroup: &other, RunAsUser: &user}, &corev1.PodSecurityContext{FSGroup: &group},
+			&corev1.PodSecurityContext{FSGroup: &group, RunAsUser: &user}, true},
+		{"removed", &corev1.PodSecurityContext{FSGroup: &group, RunAsUser: &user}, nil,
+			&corev1.PodSecurityContext{RunAsUser: &user}, true},
+	}
+	for _, tt := range tests {
+		found := &corev1.PodTemplateSpec{Spec: corev1.PodSpec{SecurityContext: tt.found}}
+		desired := &corev1.PodTemplateSpec{Spec: corev1.PodSpec{SecurityContext: tt.desired}}
+		if changed := mergeFSGroup(found, desired); changed != tt.wantChanged {
+			t.Errorf("%s: got changed %v, want %v", tt.name, changed, tt.wantChanged)
+		}
+		if !reflect.DeepEqual(found.Spec.SecurityContext, tt.want) {
+			t.Errorf("%s: got security context %v, want %v", tt.name, found.Spec.SecurityContext, tt.want)
+		}
+	}
+}
+
+func TestClaimTemplateNames(t *testing.T) {
+	tests := []struct {
+		name      string
+		templates []string
+		want      []string
+	}{
+		{"none", nil, []string{}},
+		{"data and logs", []string{dataVolumeName, logsVolumeName}, []string{dataVolumeName, logsVolumeName}},
+	}
+	for _, tt := range tests {
+		ss := &appsv1.StatefulSet{}
+		for _, name := range tt.templates {
+			claim := corev1.PersistentVolumeClaim{}
+			claim.Name = name
+			ss.Spec.VolumeClaimTemplates = append(ss.Spec.VolumeClaimTemplates, claim)
+		}
+		if got := claimTemplateNames(ss); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got names %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -3,16 +3,20 @@ package wildfly
 import (
 	"context"
 	"log"
+	"reflect"
 	"regexp"
 	"strconv"
+	"strings"
 
 	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -26,6 +30,11 @@ import (
 const (
 	containerNameString = "wildfly"
 	imageDefault        = "docker.io/jboss/wildfly"
+	// Volumes and annotations with these prefixes are owned by the operator
+	volumePrefix     = "wildfly-"
+	annotationPrefix = "wildfly.extraordy.com/"
+	// sizeAnnotation records on the Deployment the last Spec.Size applied by the operator
+	sizeAnnotation = annotationPrefix + "size"
 )
 
 // Slices and maps cannot be initialized as constants in Go
@@ -36,12 +45,23 @@ var (
 // Add creates a new Wildfly Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager) error {
-	return add(mgr, newReconciler(mgr))
+	r := newReconciler(mgr)
+	err := registerMetrics(r.metrics)
+	if err != nil {
+		return err
+	}
+	return add(mgr, r)
 }
 
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	return &ReconcileWildfly{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+func newReconciler(mgr manager.Manager) *ReconcileWildfly {
+	return &ReconcileWildfly{
+		client:                  mgr.GetClient(),
+		scheme:                  mgr.GetScheme(),
+		routeAvailable:          routeAPIAvailable(mgr.GetRESTMapper()),
+		serviceMonitorAvailable: serviceMonitorAPIAvailable(mgr.GetRESTMapper()),
+		metrics:                 newWildflyCollector(mgr.GetClient()),
+	}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -58,8 +78,7 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
-	// TODO(user): Modify this to be the types you create that are owned by the primary resource
-	// Watch for changes to secondary resource Pods and requeue the owner Wildfly
+	// Watch for changes to secondary resource Deployments and requeue the owner Wildfly
 	err = c.Watch(&source.Kind{Type: &appsv1.Deployment{}}, &handler.EnqueueRequestForOwner{
 		IsController: true,
 		OwnerType:    &wildflyv1alpha1.Wildfly{},
@@ -68,6 +87,99 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Watch for changes to secondary resource StatefulSets and requeue the owner Wildfly
+	err = c.Watch(&source.Kind{Type: &appsv1.StatefulSet{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &wildflyv1alpha1.Wildfly{},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to secondary resource Pods, i.e. the recovery pods, and requeue the owner Wildfly
+	err = c.Watch(&source.Kind{Type: &corev1.Pod{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &wildflyv1alpha1.Wildfly{},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to secondary resource Services and requeue the owner Wildfly
+	err = c.Watch(&source.Kind{Type: &corev1.Service{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &wildflyv1alpha1.Wildfly{},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to secondary resource ConfigMaps and requeue the owner Wildfly
+	err = c.Watch(&source.Kind{Type: &corev1.ConfigMap{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &wildflyv1alpha1.Wildfly{},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to the ConfigMaps referenced by a Wildfly and requeue it
+	err = c.Watch(&source.Kind{Type: &corev1.ConfigMap{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: referenceMapper(mgr.GetClient(), configMapReferences),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to secondary resource Secrets, i.e. the keystore, and requeue the owner Wildfly
+	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &wildflyv1alpha1.Wildfly{},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to the Secrets referenced by a Wildfly and requeue it
+	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: referenceMapper(mgr.GetClient(), secretReferences),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to secondary resource Ingresses and requeue the owner Wildfly
+	err = c.Watch(&source.Kind{Type: &extensionsv1beta1.Ingress{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &wildflyv1alpha1.Wildfly{},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to secondary resource Routes on OpenShift and requeue the owner Wildfly
+	if routeAPIAvailable(mgr.GetRESTMapper()) {
+		err = c.Watch(&source.Kind{Type: newRouteObject()}, &handler.EnqueueRequestForOwner{
+			IsController: true,
+			OwnerType:    &wildflyv1alpha1.Wildfly{},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Watch for changes to secondary resource ServiceMonitors with the Prometheus Operator and
+	// requeue the owner Wildfly
+	if serviceMonitorAPIAvailable(mgr.GetRESTMapper()) {
+		err = c.Watch(&source.Kind{Type: newServiceMonitorObject()}, &handler.EnqueueRequestForOwner{
+			IsController: true,
+			OwnerType:    &wildflyv1alpha1.Wildfly{},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -79,11 +191,26 @@ type ReconcileWildfly struct {
 	// that reads objects from the cache and writes to the apiserver
 	client client.Client
 	scheme *runtime.Scheme
+	// routeAvailable is set when the cluster serves the OpenShift Route API
+	routeAvailable bool
+	// serviceMonitorAvailable is set when the cluster serves the Prometheus Operator
+	// ServiceMonitor API
+	serviceMonitorAvailable bool
+	// metrics reports the state of the Wildflies on the metrics endpoint of the operator
+	metrics *wildflyCollector
 }
 
 // Reconcile reads that state of the cluster for a Wildfly object and makes changes based on the state read
 // and what is in the Wildfly.Spec
 func (r *ReconcileWildfly) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	result, err := r.reconcile(request)
+	r.metrics.reconciled(request.NamespacedName, err)
+	return result, err
+}
+
+// reconcile converges the objects of a Wildfly. The failures are counted by phase on the
+// metrics endpoint of the operator.
+func (r *ReconcileWildfly) reconcile(request reconcile.Request) (reconcile.Result, error) {
 	log.Printf("Reconciling Wildfly %s/%s\n", request.Namespace, request.Name)
 
 	// Fetch the Wildfly instance
@@ -97,65 +224,258 @@ func (r *ReconcileWildfly) Reconcile(request reconcile.Request) (reconcile.Resul
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
-		return reconcile.Result{}, err
+		return reconcileFailed(phaseFetch, err)
+	}
+	previousStatus := instance.Status.DeepCopy()
+
+	// Datasources reconciliation
+	err = r.reconcileDatasources(instance)
+	if err != nil {
+		return reconcileFailed(phaseConfiguration, err)
 	}
 
-	// Deployment reconciliation
+	// Keystore reconciliation, converted from the TLS Secret of the HTTPS listener
+	err = r.reconcileKeystore(instance)
+	if err != nil {
+		return reconcileFailed(phaseConfiguration, err)
+	}
+
+	// Management user reconciliation, hashed from the management Secret
+	err = r.reconcileManagementUser(instance)
+	if err != nil {
+		return reconcileFailed(phaseConfiguration, err)
+	}
+
+	// Bootstrap scripts reconciliation
+	err = r.reconcileBootstrap(instance)
+	if err != nil {
+		return reconcileFailed(phaseConfiguration, err)
+	}
+
+	// Service account and RBAC reconciliation for the cluster discovery
+	err = r.reconcileClustering(instance)
+	if err != nil {
+		return reconcileFailed(phaseConfiguration, err)
+	}
+
+	// JVM settings validation, the servers are not updated with a heap exceeding the memory
+	// limit of the container
+	err = validateJVM(instance)
+	if err != nil {
+		err = r.reportInvalidJVM(instance, previousStatus, err)
+		if err != nil {
+			return reconcileFailed(phaseStatus, err)
+		}
+		return reconcile.Result{}, nil
+	}
+
+	// Persistent volume claims reconciliation, shared in Deployment mode and expanded in
+	// both modes
+	err = validateStorage(instance)
+	if err != nil {
+		log.Printf("Invalid storage for Wildfly %s/%s: %v\n", instance.Namespace, instance.Name, err)
+		return reconcileFailed(phaseStorage, err)
+	}
+	err = r.reconcileVolumeClaims(instance)
+	if err != nil {
+		return reconcileFailed(phaseStorage, err)
+	}
+
+	// Workload reconciliation, the servers run either in a Deployment or in a StatefulSet
+	var workload runtime.Object
+	var requeue bool
+	if statefulSetMode(instance) {
+		workload, requeue, err = r.reconcileStatefulSet(instance)
+	} else {
+		workload, requeue, err = r.reconcileDeployment(instance)
+	}
+	if err != nil {
+		return reconcileFailed(phaseDeployment, err)
+	}
+	if requeue {
+		return reconcile.Result{Requeue: true}, nil
+	}
+
+	// Remove the workload of the previous mode once the current one is ready
+	err = r.removeStaleWorkload(instance, workload)
+	if err != nil {
+		return reconcileFailed(phaseDeployment, err)
+	}
+
+	// Service reconciliation
+	foundSvc, requeue, err := r.reconcileService(r.newWildflyService(instance))
+	if err != nil {
+		return reconcileFailed(phaseService, err)
+	}
+	if requeue {
+		return reconcile.Result{Requeue: true}, nil
+	}
+
+	// Headless Service reconciliation, used by the StatefulSet and by DNS_PING
+	err = r.reconcileHeadlessService(instance)
+	if err != nil {
+		return reconcileFailed(phaseService, err)
+	}
+
+	// Route or Ingress reconciliation, for the exposure outside of the cluster
+	err = r.reconcileExpose(instance)
+	if err != nil {
+		return reconcileFailed(phaseExpose, err)
+	}
+
+	// ServiceMonitor reconciliation, for the scraping of the metrics by Prometheus
+	err = r.reconcileMonitoring(instance)
+	if err != nil {
+		return reconcileFailed(phaseMonitoring, err)
+	}
+
+	// Status reconciliation
+	err = r.updateStatus(instance, previousStatus, workload, foundSvc)
+	if err != nil {
+		return reconcileFailed(phaseStatus, err)
+	}
+
+	// Reload of the servers reporting reload-required in the status, one at a time
+	err = r.reconcileReload(instance)
+	if err != nil {
+		return reconcileFailed(phaseReload, err)
+	}
+
+	// The draining of the transaction logs and the runtime state of the servers are polled
+	// through the management interface
+	if draining(instance) {
+		return reconcile.Result{RequeueAfter: scaleDownPollInterval}, nil
+	}
+	if managementEnabled(instance) {
+		return reconcile.Result{RequeueAfter: serverStatePollInterval}, nil
+	}
+	return reconcile.Result{}, nil
+}
+
+// reconcileDeployment creates the Deployment of the Wildfly or converges the fields owned by
+// the operator, and returns it. The request must be requeued when the Deployment was changed.
+func (r *ReconcileWildfly) reconcileDeployment(cr *wildflyv1alpha1.Wildfly) (*appsv1.Deployment, bool, error) {
 	foundDep := &appsv1.Deployment{}
-	err = r.client.Get(context.TODO(), types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, foundDep)
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: cr.Name, Namespace: cr.Namespace}, foundDep)
 	if err != nil && errors.IsNotFound(err) {
 		// Define new Wildfly Deployment
-		dep := r.newWildflyDeployment(instance)
+		dep, err := r.newWildflyDeployment(cr)
+		if err != nil {
+			log.Printf("Failed to define new Wildfly Deployment: %v\n", err)
+			return nil, false, err
+		}
 		log.Printf("Creating a new Wildfly Deployment: %s/%s\n", dep.Namespace, dep.Name)
 		err = r.client.Create(context.TODO(), dep)
 		if err != nil {
 			log.Printf("Failed to create new Wildfly Deployment: %v\n", err)
-			return reconcile.Result{}, err
+			return nil, false, err
 		}
 		// After successful deployment return and requeue
-		return reconcile.Result{Requeue: true}, nil
+		return dep, true, nil
 	} else if err != nil {
 		log.Printf("Failed to get Deployment: %v\n", err)
-		return reconcile.Result{}, err
+		return nil, false, err
 	}
 
-	// Reconcile deployment size
-	size := instance.Spec.Size
-	if *foundDep.Spec.Replicas != size {
-		foundDep.Spec.Replicas = &size
+	// Converge the fields owned by the operator towards the desired Deployment
+	desiredDep, err := r.newWildflyDeployment(cr)
+	if err != nil {
+		log.Printf("Failed to define desired Wildfly Deployment: %v\n", err)
+		return nil, false, err
+	}
+	if r.mergeDeployment(cr, foundDep, desiredDep) {
+		log.Printf("Updating Wildfly Deployment: %s/%s\n", foundDep.Namespace, foundDep.Name)
 		err = r.client.Update(context.TODO(), foundDep)
 		if err != nil {
 			log.Printf("Failed to update Wildfly Deployment: %v\n", err)
-			return reconcile.Result{}, err
+			return nil, false, err
 		}
-		return reconcile.Result{Requeue: true}, nil
+		return foundDep, true, nil
 	}
+	return foundDep, false, nil
+}
 
-	// Service reconciliation
+// reconcileService creates the given Service or converges the existing one towards it, and
+// returns the Service found in the cluster. The request must be requeued when the Service
+// was changed.
+func (r *ReconcileWildfly) reconcileService(desiredSvc *corev1.Service) (*corev1.Service, bool, error) {
 	foundSvc := &corev1.Service{}
-	err = r.client.Get(context.TODO(), types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, foundSvc)
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: desiredSvc.Name, Namespace: desiredSvc.Namespace}, foundSvc)
 	if err != nil && errors.IsNotFound(err) {
-		// Define a new Wildfly Service
-		svc := r.newWildflyService(instance)
-		log.Printf("Creating a new Wildfly Service: %s/%s\n", svc.Namespace, svc.Name)
-		err = r.client.Create(context.TODO(), svc)
+		log.Printf("Creating a new Wildfly Service: %s/%s\n", desiredSvc.Namespace, desiredSvc.Name)
+		err = r.client.Create(context.TODO(), desiredSvc)
 		if err != nil {
 			log.Printf("Failed to create new Wildfly Service: %v\n", err)
-			return reconcile.Result{}, err
+			return nil, false, err
 		}
-		return reconcile.Result{Requeue: true}, nil
+		return desiredSvc, true, nil
 	} else if err != nil {
 		log.Printf("Failed to get Service: %v\n", err)
-		return reconcile.Result{}, err
+		return nil, false, err
 	}
 
-	return reconcile.Result{}, nil
+	// Converge type, ports and selector of the existing Service
+	if r.mergeService(foundSvc, desiredSvc) {
+		log.Printf("Updating Wildfly Service: %s/%s\n", foundSvc.Namespace, foundSvc.Name)
+		err = r.client.Update(context.TODO(), foundSvc)
+		if err != nil {
+			log.Printf("Failed to update Wildfly Service: %v\n", err)
+			return nil, false, err
+		}
+		return foundSvc, true, nil
+	}
+	return foundSvc, false, nil
 }
 
 // newWildflyDeployment manages the creation of a wildfly Deployment
-func (r *ReconcileWildfly) newWildflyDeployment(cr *wildflyv1alpha1.Wildfly) *appsv1.Deployment {
+func (r *ReconcileWildfly) newWildflyDeployment(cr *wildflyv1alpha1.Wildfly) (*appsv1.Deployment, error) {
+	replicas := desiredReplicas(cr)
+	labels := map[string]string{
+		"app": cr.Name,
+	}
+
+	template, err := r.newPodTemplate(cr)
+	if err != nil {
+		return nil, err
+	}
+
+	dep := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        cr.Name,
+			Namespace:   cr.Namespace,
+			Annotations: map[string]string{sizeAnnotation: strconv.Itoa(int(replicas))},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: template,
+			Strategy: appsv1.DeploymentStrategy{
+				Type: deploymentStrategy(cr),
+			},
+		},
+	}
+	controllerutil.SetControllerReference(cr, dep, r.scheme)
+	return dep, nil
+}
+
+// desiredReplicas returns the number of replicas requested by the custom resource
+func desiredReplicas(cr *wildflyv1alpha1.Wildfly) int32 {
+	// Don' accept negative replicas
+	if cr.Spec.Size < 0 {
+		return 1
+	}
+	return cr.Spec.Size
+}
+
+// newPodTemplate returns the pod template shared by the Deployment and the StatefulSet
+func (r *ReconcileWildfly) newPodTemplate(cr *wildflyv1alpha1.Wildfly) (corev1.PodTemplateSpec, error) {
 	// cr variables declaration
-	var replicas int32
 	var imageString string
 	var imageTag string
 	var commandSlice []string
@@ -164,13 +484,6 @@ func (r *ReconcileWildfly) newWildflyDeployment(cr *wildflyv1alpha1.Wildfly) *ap
 		"app": cr.Name,
 	}
 
-	// Don' accept negative replicas
-	if cr.Spec.Size < 0 {
-		replicas = 1
-	} else {
-		replicas = cr.Spec.Size
-	}
-
 	// If no image name is assigned we default to docker.io/jboss/wildfly
 	if cr.Spec.Image == "" {
 		imageString = imageDefault
@@ -185,44 +498,250 @@ func (r *ReconcileWildfly) newWildflyDeployment(cr *wildflyv1alpha1.Wildfly) *ap
 		imageTag = cr.Spec.Version
 	}
 
-	// Pass a default command slice if nothing is provided
+	// Pass a default command slice if nothing is provided. The management interface is bound
+	// to all the addresses when the operator, the kubelet or Prometheus need to reach it, and a clustered
+	// server runs the HA profile with JGroups bound to the address of the pod. The system
+	// properties are passed as server arguments.
 	if cr.Spec.Cmd == nil {
-		commandSlice = commandDefault
+		commandSlice = append([]string{}, commandDefault...)
+		if managementEnabled(cr) || httpProbesEnabled(cr) || monitoringEnabled(cr) {
+			commandSlice = append(commandSlice, "-bmanagement", "0.0.0.0")
+		}
+		if clusteringEnabled(cr) {
+			commandSlice = append(commandSlice, "-c", serverConfig(cr), "-bprivate", "$("+podIPEnvVar+")")
+		}
+		commandSlice = append(commandSlice, systemPropertyFlags(cr)...)
 	} else {
 		commandSlice = cr.Spec.Cmd
 	}
 
-	dep := &appsv1.Deployment{
-		TypeMeta: metav1.TypeMeta{
-			APIVersion: "apps/v1",
-			Kind:       "Deployment",
-		},
+	template := corev1.PodTemplateSpec{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      cr.Name,
-			Namespace: cr.Namespace,
+			Labels: labels,
 		},
-		Spec: appsv1.DeploymentSpec{
-			Replicas: &replicas,
-			Selector: &metav1.LabelSelector{
-				MatchLabels: labels,
-			},
-			Template: corev1.PodTemplateSpec{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels: labels,
-				},
-				Spec: corev1.PodSpec{
-					Containers: []corev1.Container{{
-						Name:    containerNameString,
-						Image:   imageString + ":" + imageTag,
-						Command: commandSlice,
-						Ports:   r.loadContainerPorts(cr),
-					}},
-				},
-			},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:      containerNameString,
+				Image:     imageString + ":" + imageTag,
+				Command:   commandSlice,
+				Ports:     r.loadContainerPorts(cr),
+				Resources: desiredResources(cr),
+			}},
 		},
 	}
-	controllerutil.SetControllerReference(cr, dep, r.scheme)
-	return dep
+
+	err := r.addEnv(cr, &template)
+	if err != nil {
+		return template, err
+	}
+	r.addProbes(cr, &template)
+	r.addGracefulShutdown(cr, &template)
+	addClustering(cr, &template)
+	addBootstrap(cr, &template)
+	r.addStorage(cr, &template)
+
+	err = r.addConfigMap(cr, &template)
+	if err != nil {
+		return template, err
+	}
+	err = r.addCliScripts(cr, &template)
+	if err != nil {
+		return template, err
+	}
+	err = r.addManagementUser(cr, &template)
+	if err != nil {
+		return template, err
+	}
+	err = r.addHTTPS(cr, &template)
+	if err != nil {
+		return template, err
+	}
+	err = r.addDatasources(cr, &template)
+	if err != nil {
+		return template, err
+	}
+	err = r.addApplications(cr, &template)
+	if err != nil {
+		return template, err
+	}
+	err = addDrivers(cr, &template)
+	if err != nil {
+		return template, err
+	}
+
+	return template, nil
+}
+
+// mergeDeployment copies the fields owned by the operator from the desired Deployment into
+// the found one and reports whether anything changed. The replicas are only set when Spec.Size
+// changed since it was last applied, so that a HorizontalPodAutoscaler can scale the
+// Deployment in between.
+func (r *ReconcileWildfly) mergeDeployment(cr *wildflyv1alpha1.Wildfly, found, desired *appsv1.Deployment) bool {
+	changed := false
+
+	size := desired.Annotations[sizeAnnotation]
+	if found.Annotations[sizeAnnotation] != size {
+		if found.Annotations == nil {
+			found.Annotations = map[string]string{}
+		}
+		found.Annotations[sizeAnnotation] = size
+		found.Spec.Replicas = desired.Spec.Replicas
+		changed = true
+	}
+
+	// The parameters of the rolling update are defaulted by the API server, only the type
+	// is owned
+	if found.Spec.Strategy.Type != desired.Spec.Strategy.Type {
+		found.Spec.Strategy = desired.Spec.Strategy
+		changed = true
+	}
+
+	if r.mergePodTemplate(cr, &found.Spec.Template, &desired.Spec.Template) {
+		changed = true
+	}
+	return changed
+}
+
+// mergePodTemplate copies the fields owned by the operator from the desired pod template into
+// the found one and reports whether anything changed. Only the labels, the termination grace
+// period, the service account set by the operator, the file system group of the pod, the
+// annotations, volumes and init containers prefixed as owned by the operator and the wildfly
+// container are touched: containers and volumes injected by other actors (e.g. sidecars) and
+// any other field of the pod template are left as they are.
+func (r *ReconcileWildfly) mergePodTemplate(cr *wildflyv1alpha1.Wildfly, found, desired *corev1.PodTemplateSpec) bool {
+	changed := false
+
+	if found.Labels == nil {
+		found.Labels = map[string]string{}
+	}
+	for k, v := range desired.Labels {
+		if found.Labels[k] != v {
+			found.Labels[k] = v
+			changed = true
+		}
+	}
+
+	annotations := mergeOwnedAnnotations(found.Annotations, desired.Annotations)
+	if !reflect.DeepEqual(found.Annotations, annotations) {
+		found.Annotations = annotations
+		changed = true
+	}
+
+	if !reflect.DeepEqual(found.Spec.TerminationGracePeriodSeconds, desired.Spec.TerminationGracePeriodSeconds) {
+		found.Spec.TerminationGracePeriodSeconds = desired.Spec.TerminationGracePeriodSeconds
+		changed = true
+	}
+
+	volumes := mergeOwnedVolumes(found.Spec.Volumes, desired.Spec.Volumes)
+	if !reflect.DeepEqual(found.Spec.Volumes, volumes) {
+		found.Spec.Volumes = volumes
+		changed = true
+	}
+
+	initContainers := mergeOwnedInitContainers(found.Spec.InitContainers, desired.Spec.InitContainers)
+	if !reflect.DeepEqual(found.Spec.InitContainers, initContainers) {
+		found.Spec.InitContainers = initContainers
+		changed = true
+	}
+
+	if mergeServiceAccount(cr, found, desired) {
+		changed = true
+	}
+
+	if mergeFSGroup(found, desired) {
+		changed = true
+	}
+
+	desiredContainer := desired.Spec.Containers[0]
+	foundContainer := findContainer(found.Spec.Containers, containerNameString)
+	if foundContainer == nil {
+		// The wildfly container was removed by hand, put it back in front of the others
+		found.Spec.Containers = append([]corev1.Container{desiredContainer}, found.Spec.Containers...)
+		return true
+	}
+	if foundContainer.Image != desiredContainer.Image {
+		foundContainer.Image = desiredContainer.Image
+		changed = true
+	}
+	if !reflect.DeepEqual(foundContainer.Command, desiredContainer.Command) {
+		foundContainer.Command = desiredContainer.Command
+		changed = true
+	}
+	if !reflect.DeepEqual(foundContainer.Ports, desiredContainer.Ports) {
+		foundContainer.Ports = desiredContainer.Ports
+		changed = true
+	}
+	if !reflect.DeepEqual(foundContainer.VolumeMounts, desiredContainer.VolumeMounts) {
+		foundContainer.VolumeMounts = desiredContainer.VolumeMounts
+		changed = true
+	}
+	if !resourcesEqual(foundContainer.Resources, desiredContainer.Resources) {
+		foundContainer.Resources = desiredContainer.Resources
+		changed = true
+	}
+	if !reflect.DeepEqual(foundContainer.Env, desiredContainer.Env) {
+		foundContainer.Env = desiredContainer.Env
+		changed = true
+	}
+	if !reflect.DeepEqual(foundContainer.EnvFrom, desiredContainer.EnvFrom) {
+		foundContainer.EnvFrom = desiredContainer.EnvFrom
+		changed = true
+	}
+	if !reflect.DeepEqual(foundContainer.LivenessProbe, desiredContainer.LivenessProbe) {
+		foundContainer.LivenessProbe = desiredContainer.LivenessProbe
+		changed = true
+	}
+	if !reflect.DeepEqual(foundContainer.ReadinessProbe, desiredContainer.ReadinessProbe) {
+		foundContainer.ReadinessProbe = desiredContainer.ReadinessProbe
+		changed = true
+	}
+	if !reflect.DeepEqual(foundContainer.Lifecycle, desiredContainer.Lifecycle) {
+		foundContainer.Lifecycle = desiredContainer.Lifecycle
+		changed = true
+	}
+
+	return changed
+}
+
+// mergeOwnedAnnotations returns the found annotations with the ones owned by the operator
+// replaced by the desired set. Annotations set by other actors are preserved.
+func mergeOwnedAnnotations(found, desired map[string]string) map[string]string {
+	merged := map[string]string{}
+	for k, v := range found {
+		if !strings.HasPrefix(k, annotationPrefix) {
+			merged[k] = v
+		}
+	}
+	for k, v := range desired {
+		merged[k] = v
+	}
+	if len(merged) == 0 && found == nil {
+		return nil
+	}
+	return merged
+}
+
+// mergeOwnedVolumes returns the found volumes with the ones owned by the operator replaced
+// by the desired set. Volumes added by other actors are preserved.
+func mergeOwnedVolumes(found, desired []corev1.Volume) []corev1.Volume {
+	var merged []corev1.Volume
+	for _, v := range found {
+		if !strings.HasPrefix(v.Name, volumePrefix) {
+			merged = append(merged, v)
+		}
+	}
+	return append(merged, desired...)
+}
+
+// findContainer returns a pointer to the container with the given name, or nil if the
+// slice does not contain it.
+func findContainer(containers []corev1.Container, name string) *corev1.Container {
+	for i := range containers {
+		if containers[i].Name == name {
+			return &containers[i]
+		}
+	}
+	return nil
 }
 
 // loadContainerPorts creates a []corev1.ContainerPort slice with all the ports defined in the
@@ -249,6 +768,13 @@ func (r *ReconcileWildfly) loadContainerPorts(cr *wildflyv1alpha1.Wildfly) []cor
 			Protocol:      corev1.ProtocolTCP,
 		})
 	}
+	if managementExposed(cr) {
+		containerPorts = append(containerPorts, corev1.ContainerPort{
+			Name:          managementServicePortName,
+			ContainerPort: managementPort,
+			Protocol:      corev1.ProtocolTCP,
+		})
+	}
 	log.Printf("Completed loading ports: %v", containerPorts)
 	return containerPorts
 }
@@ -258,6 +784,9 @@ func (r *ReconcileWildfly) newWildflyService(cr *wildflyv1alpha1.Wildfly) *corev
 	labels := map[string]string{
 		"app": cr.Name,
 	}
+	selector := map[string]string{
+		"app": cr.Name,
+	}
 	svc := &corev1.Service{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "v1",
@@ -269,7 +798,7 @@ func (r *ReconcileWildfly) newWildflyService(cr *wildflyv1alpha1.Wildfly) *corev
 			Labels:    labels,
 		},
 		Spec: corev1.ServiceSpec{
-			Selector: labels,
+			Selector: selector,
 			Ports:    r.loadServicePorts(cr),
 		},
 	}
@@ -290,27 +819,85 @@ func (r *ReconcileWildfly) loadServicePorts(cr *wildflyv1alpha1.Wildfly) []corev
 	servicePorts := []corev1.ServicePort{}
 	if cr.Spec.Ports != nil {
 		for _, p := range cr.Spec.Ports {
-			sp := corev1.ServicePort{
-				Name:     "port-" + strconv.Itoa(int(p.Port)),
-				Port:     int32(p.Port),
-				Protocol: r.matchProtocol(p),
-			}
-			servicePorts = append(servicePorts, sp)
+			servicePorts = append(servicePorts, newServicePort(p.Port, r.matchProtocol(p)))
 		}
 	} else {
 		// Manage defaults if no ports are provided by user
-		servicePorts = append(servicePorts, corev1.ServicePort{
-			Port:     8080,
-			Protocol: corev1.ProtocolTCP,
-		})
-		servicePorts = append(servicePorts, corev1.ServicePort{
-			Port:     8443,
-			Protocol: corev1.ProtocolTCP,
-		})
+		servicePorts = append(servicePorts, newServicePort(8080, corev1.ProtocolTCP))
+		servicePorts = append(servicePorts, newServicePort(8443, corev1.ProtocolTCP))
+	}
+	if managementExposed(cr) {
+		servicePorts = append(servicePorts, newManagementServicePort())
 	}
 	return servicePorts
 }
 
+// newServicePort returns a named ServicePort targeting the same port on the pods. Names are
+// mandatory for multi-port Services and the target port is set explicitly to match the value
+// defaulted by the API server, so that the Service does not look changed on every reconcile.
+func newServicePort(port int32, protocol corev1.Protocol) corev1.ServicePort {
+	return corev1.ServicePort{
+		Name:       "port-" + strconv.Itoa(int(port)),
+		Port:       port,
+		TargetPort: intstr.FromInt(int(port)),
+		Protocol:   protocol,
+	}
+}
+
+// mergeService copies type, ports, selector and the publication of not ready addresses from
+// the desired Service into the found one, along with the labels owned by the operator, and
+// reports whether anything changed. The allocated ClusterIP is never touched, and the NodePort
+// numbers already assigned to existing ports are preserved as long as the Service stays of
+// type NodePort.
+func (r *ReconcileWildfly) mergeService(found, desired *corev1.Service) bool {
+	changed := false
+
+	desiredType := desired.Spec.Type
+	if desiredType == "" {
+		desiredType = corev1.ServiceTypeClusterIP
+	}
+	if found.Spec.Type != desiredType {
+		found.Spec.Type = desiredType
+		changed = true
+	}
+
+	if !reflect.DeepEqual(found.Spec.Selector, desired.Spec.Selector) {
+		found.Spec.Selector = desired.Spec.Selector
+		changed = true
+	}
+
+	// The owned labels share the prefix of the owned annotations
+	labels := mergeOwnedAnnotations(found.Labels, desired.Labels)
+	if !reflect.DeepEqual(found.Labels, labels) {
+		found.Labels = labels
+		changed = true
+	}
+
+	if found.Spec.PublishNotReadyAddresses != desired.Spec.PublishNotReadyAddresses {
+		found.Spec.PublishNotReadyAddresses = desired.Spec.PublishNotReadyAddresses
+		changed = true
+	}
+
+	ports := make([]corev1.ServicePort, 0, len(desired.Spec.Ports))
+	for _, dp := range desired.Spec.Ports {
+		if desiredType == corev1.ServiceTypeNodePort {
+			for _, fp := range found.Spec.Ports {
+				if fp.Port == dp.Port && fp.Protocol == dp.Protocol {
+					dp.NodePort = fp.NodePort
+					break
+				}
+			}
+		}
+		ports = append(ports, dp)
+	}
+	if !reflect.DeepEqual(found.Spec.Ports, ports) {
+		found.Spec.Ports = ports
+		changed = true
+	}
+
+	return changed
+}
+
 // matchProtocol uses simple regular expressions do match the port protocol. If no value or
 // wrong content is passed it assumes TCP as the default.
 func (r ReconcileWildfly) matchProtocol(p wildflyv1alpha1.WildflyPortProto) corev1.Protocol {
@@ -0,0 +1,215 @@
+package wildfly
+
+import (
+	"reflect"
+	"testing"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// newTemplate returns a pod template running the wildfly container with the given image
+func newTemplate(image string) *corev1.PodTemplateSpec {
+	return &corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: containerNameString, Image: image}},
+		},
+	}
+}
+
+func TestMergePodTemplate(t *testing.T) {
+	cr := &wildflyv1alpha1.Wildfly{}
+	cr.Name = "example"
+	sidecar := corev1.Container{Name: "sidecar", Image: "sidecar:1"}
+	grace := int64(30)
+
+	tests := []struct {
+		name        string
+		found       func() *corev1.PodTemplateSpec
+		desired     func() *corev1.PodTemplateSpec
+		want        func() *corev1.PodTemplateSpec
+		wantChanged bool
+	}{
+		{
+			name:        "unchanged",
+			found:       func() *corev1.PodTemplateSpec { return newTemplate("wildfly:17") },
+			desired:     func() *corev1.PodTemplateSpec { return newTemplate("wildfly:17") },
+			want:        func() *corev1.PodTemplateSpec { return newTemplate("wildfly:17") },
+			wantChanged: false,
+		},
+		{
+			name:        "image changed",
+			found:       func() *corev1.PodTemplateSpec { return newTemplate("wildfly:17") },
+			desired:     func() *corev1.PodTemplateSpec { return newTemplate("wildfly:18") },
+			want:        func() *corev1.PodTemplateSpec { return newTemplate("wildfly:18") },
+			wantChanged: true,
+		},
+		{
+			name: "sidecar, foreign volume and annotation preserved",
+			found: func() *corev1.PodTemplateSpec {
+				tpl := newTemplate("wildfly:17")
+				tpl.Annotations = map[string]string{"sidecar.istio.io/status": "injected", annotationPrefix + "old": "x"}
+				tpl.Spec.Containers = append(tpl.Spec.Containers, sidecar)
+				tpl.Spec.Volumes = []corev1.Volume{{Name: "istio-envoy"}, {Name: volumePrefix + "old"}}
+				return tpl
+			},
+			desired: func() *corev1.PodTemplateSpec {
+				tpl := newTemplate("wildfly:17")
+				tpl.Annotations = map[string]string{annotationPrefix + "config-hash": "1"}
+				tpl.Spec.Volumes = []corev1.Volume{{Name: volumePrefix + "config"}}
+				return tpl
+			},
+			want: func() *corev1.PodTemplateSpec {
+				tpl := newTemplate("wildfly:17")
+				tpl.Annotations = map[string]string{"sidecar.istio.io/status": "injected", annotationPrefix + "config-hash": "1"}
+				tpl.Spec.Containers = append(tpl.Spec.Containers, sidecar)
+				tpl.Spec.Volumes = []corev1.Volume{{Name: "istio-envoy"}, {Name: volumePrefix + "config"}}
+				return tpl
+			},
+			wantChanged: true,
+		},
+		{
+			name: "wildfly container removed by hand",
+			found: func() *corev1.PodTemplateSpec {
+				tpl := newTemplate("wildfly:17")
+				tpl.Spec.Containers = []corev1.Container{sidecar}
+				return tpl
+			},
+			desired: func() *corev1.PodTemplateSpec { return newTemplate("wildfly:17") },
+			want: func() *corev1.PodTemplateSpec {
+				tpl := newTemplate("wildfly:17")
+				tpl.Spec.Containers = append(tpl.Spec.Containers, sidecar)
+				return tpl
+			},
+			wantChanged: true,
+		},
+		{
+			name: "probes and grace period",
+			found: func() *corev1.PodTemplateSpec {
+				return newTemplate("wildfly:17")
+			},
+			desired: func() *corev1.PodTemplateSpec {
+				tpl := newTemplate("wildfly:17")
+				tpl.Spec.TerminationGracePeriodSeconds = &grace
+				tpl.Spec.Containers[0].ReadinessProbe = newProbe(nil, httpProbeHandler(readinessPath), readinessInitialDelayDefault, probeTimeoutDefault)
+				return tpl
+			},
+			want: func() *corev1.PodTemplateSpec {
+				tpl := newTemplate("wildfly:17")
+				tpl.Spec.TerminationGracePeriodSeconds = &grace
+				tpl.Spec.Containers[0].ReadinessProbe = newProbe(nil, httpProbeHandler(readinessPath), readinessInitialDelayDefault, probeTimeoutDefault)
+				return tpl
+			},
+			wantChanged: true,
+		},
+		{
+			name: "foreign service account preserved",
+			found: func() *corev1.PodTemplateSpec {
+				tpl := newTemplate("wildfly:17")
+				tpl.Spec.ServiceAccountName = "custom"
+				return tpl
+			},
+			desired: func() *corev1.PodTemplateSpec { return newTemplate("wildfly:17") },
+			want: func() *corev1.PodTemplateSpec {
+				tpl := newTemplate("wildfly:17")
+				tpl.Spec.ServiceAccountName = "custom"
+				return tpl
+			},
+			wantChanged: false,
+		},
+	}
+	for _, tt := range tests {
+		r := &ReconcileWildfly{}
+		found := tt.found()
+		if changed := r.mergePodTemplate(cr, found, tt.desired()); changed != tt.wantChanged {
+			t.Errorf("%s: got changed %v, want %v", tt.name, changed, tt.wantChanged)
+		}
+		want := tt.want()
+		if want.Labels == nil {
+			want.Labels = map[string]string{}
+		}
+		if want.Annotations == nil {
+			want.Annotations = map[string]string{}
+		}
+		if found.Annotations == nil {
+			found.Annotations = map[string]string{}
+		}
+		if !reflect.DeepEqual(found, want) {
+			t.Errorf("%s: got template %+v, want %+v", tt.name, found, want)
+		}
+	}
+}
+
+func TestMergeOwnedAnnotations(t *testing.T) {
+	tests := []struct {
+		name           string
+		found, desired map[string]string
+		want           map[string]string
+	}{
+		{"nil", nil, nil, nil},
+		{"empty", map[string]string{}, nil, map[string]string{}},
+		{"foreign kept", map[string]string{"a": "1"}, nil, map[string]string{"a": "1"}},
+		{"owned replaced", map[string]string{annotationPrefix + "a": "1", annotationPrefix + "b": "2"},
+			map[string]string{annotationPrefix + "a": "3"}, map[string]string{annotationPrefix + "a": "3"}},
+		{"foreign and owned", map[string]string{"a": "1", annotationPrefix + "b": "2"},
+			map[string]string{annotationPrefix + "c": "3"}, map[string]string{"a": "1", annotationPrefix + "c": "3"}},
+	}
+	for _, tt := range tests {
+		if got := mergeOwnedAnnotations(tt.found, tt.desired); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got annotations %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMergeService(t *testing.T) {
+	port := func(p, nodePort int32) corev1.ServicePort {
+		sp := newServicePort(p, corev1.ProtocolTCP)
+		sp.NodePort = nodePort
+		return sp
+	}
+	service := func(serviceType corev1.ServiceType, ports ...corev1.ServicePort) *corev1.Service {
+		svc := &corev1.Service{}
+		svc.Labels = map[string]string{"app": "example"}
+		svc.Spec.Type = serviceType
+		svc.Spec.ClusterIP = "10.0.0.1"
+		svc.Spec.Selector = map[string]string{"app": "example"}
+		svc.Spec.Ports = ports
+		return svc
+	}
+
+	tests := []struct {
+		name        string
+		found       *corev1.Service
+		desired     *corev1.Service
+		want        *corev1.Service
+		wantChanged bool
+	}{
+		{"unchanged", service(corev1.ServiceTypeClusterIP, port(8080, 0)), service("", port(8080, 0)),
+			service(corev1.ServiceTypeClusterIP, port(8080, 0)), false},
+		{"port added", service(corev1.ServiceTypeClusterIP, port(8080, 0)), service("", port(8080, 0), port(8443, 0)),
+			service(corev1.ServiceTypeClusterIP, port(8080, 0), port(8443, 0)), true},
+		{"node ports kept", service(corev1.ServiceTypeNodePort, port(8080, 30080)),
+			service(corev1.ServiceTypeNodePort, port(8080, 0), port(8443, 0)),
+			service(corev1.ServiceTypeNodePort, port(8080, 30080), port(8443, 0)), true},
+		{"node ports dropped", service(corev1.ServiceTypeNodePort, port(8080, 30080)), service("", port(8080, 0)),
+			service(corev1.ServiceTypeClusterIP, port(8080, 0)), true},
+	}
+	for _, tt := range tests {
+		r := &ReconcileWildfly{}
+		if changed := r.mergeService(tt.found, tt.desired); changed != tt.wantChanged {
+			t.Errorf("%s: got changed %v, want %v", tt.name, changed, tt.wantChanged)
+		}
+		if !reflect.DeepEqual(tt.found, tt.want) {
+			t.Errorf("%s: got Service %+v, want %+v", tt.name, tt.found.Spec, tt.want.Spec)
+		}
+	}
+}
+
+func TestNewServicePort(t *testing.T) {
+	got := newServicePort(8080, corev1.ProtocolTCP)
+	want := corev1.ServicePort{Name: "port-8080", Port: 8080, TargetPort: intstr.FromInt(8080), Protocol: corev1.ProtocolTCP}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got port %+v, want %+v", got, want)
+	}
+}
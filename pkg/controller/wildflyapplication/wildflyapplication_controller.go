@@ -0,0 +1,444 @@
+package wildflyapplication
+
+import (
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+	"github.com/giannisalinetti/wildfly-operator/pkg/controller/servers"
+	"github.com/giannisalinetti/wildfly-operator/pkg/management"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// undeployFinalizer makes the operator undeploy the application before the resource is deleted
+	undeployFinalizer = "wildfly.extraordy.com/undeploy"
+	// pollInterval is how often the deployments are checked, e.g. on servers restarted with
+	// their initial configuration
+	pollInterval = 30 * time.Second
+	// downloadTimeout bounds the download of an artifact
+	downloadTimeout = 5 * time.Minute
+	// notDeployed is the status of a server on which the application is not deployed
+	notDeployed = "NOT_DEPLOYED"
+)
+
+// Add creates a new WildflyApplication Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileWildflyApplication{
+		client:     mgr.GetClient(),
+		scheme:     mgr.GetScheme(),
+		httpClient: &http.Client{Timeout: downloadTimeout},
+	}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New("wildflyapplication-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to primary resource WildflyApplication
+	err = c.Watch(&source.Kind{Type: &wildflyv1alpha1.WildflyApplication{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to the pods of a Wildfly and requeue the applications deployed on it,
+	// so that restarted or added servers get the applications
+	err = c.Watch(&source.Kind{Type: &corev1.Pod{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: wildflyMapper(mgr.GetClient(), func(obj handler.MapObject) string {
+			return obj.Meta.GetLabels()["app"]
+		}),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to the Wildfly resources and requeue the applications deployed on them
+	err = c.Watch(&source.Kind{Type: &wildflyv1alpha1.Wildfly{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: wildflyMapper(mgr.GetClient(), func(obj handler.MapObject) string {
+			return obj.Meta.GetName()
+		}),
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// wildflyMapper returns a Mapper that enqueues the applications of the namespace of the event
+// object deployed on the Wildfly named by the wildfly function
+func wildflyMapper(c client.Client, wildfly func(handler.MapObject) string) handler.Mapper {
+	return handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+		name := wildfly(obj)
+		if name == "" {
+			return nil
+		}
+		appList := &wildflyv1alpha1.WildflyApplicationList{}
+		err := c.List(context.TODO(), client.InNamespace(obj.Meta.GetNamespace()), appList)
+		if err != nil {
+			log.Printf("Failed to list WildflyApplication resources: %v\n", err)
+			return nil
+		}
+		requests := []reconcile.Request{}
+		for _, app := range appList.Items {
+			if app.Spec.Wildfly == name {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+					Name:      app.Name,
+					Namespace: app.Namespace,
+				}})
+			}
+		}
+		return requests
+	})
+}
+
+var _ reconcile.Reconciler = &ReconcileWildflyApplication{}
+
+// ReconcileWildflyApplication reconciles a WildflyApplication object
+type ReconcileWildflyApplication struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	client client.Client
+	scheme *runtime.Scheme
+	// httpClient downloads the artifacts
+	httpClient *http.Client
+}
+
+// Reconcile deploys the artifact of a WildflyApplication on every running server of the
+// referenced Wildfly through the management interface, rolls back to the previous artifact
+// when the deployment fails and undeploys the application when the resource is deleted.
+func (r *ReconcileWildflyApplication) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	log.Printf("Reconciling WildflyApplication %s/%s\n", request.Namespace, request.Name)
+
+	// Fetch the WildflyApplication instance
+	instance := &wildflyv1alpha1.WildflyApplication{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	previousStatus := instance.Status.DeepCopy()
+
+	if instance.DeletionTimestamp != nil {
+		return reconcile.Result{}, r.finalize(instance)
+	}
+	if !hasFinalizer(instance) {
+		instance.Finalizers = append(instance.Finalizers, undeployFinalizer)
+		err = r.client.Update(context.TODO(), instance)
+		if err != nil {
+			log.Printf("Failed to add finalizer to WildflyApplication: %v\n", err)
+		}
+		return reconcile.Result{Requeue: true}, err
+	}
+
+	clients, err := r.managementClients(instance)
+	if err != nil {
+		log.Printf("Failed to reach the servers of WildflyApplication %s/%s: %v\n", instance.Namespace, instance.Name, err)
+		instance.Status.Phase = wildflyv1alpha1.ApplicationPending
+		instance.Status.Message = err.Error()
+		return reconcile.Result{RequeueAfter: pollInterval}, r.updateStatus(instance, previousStatus)
+	}
+
+	err = r.deploy(instance, clients)
+	if err != nil {
+		log.Printf("Failed to deploy WildflyApplication %s/%s: %v\n", instance.Namespace, instance.Name, err)
+		instance.Status.Phase = wildflyv1alpha1.ApplicationFailed
+		instance.Status.Message = err.Error()
+	}
+	if statusErr := r.updateStatus(instance, previousStatus); statusErr != nil {
+		return reconcile.Result{}, statusErr
+	}
+	return reconcile.Result{RequeueAfter: pollInterval}, nil
+}
+
+// updateStatus writes the status of the application when it changed
+func (r *ReconcileWildflyApplication) updateStatus(cr *wildflyv1alpha1.WildflyApplication, previous *wildflyv1alpha1.WildflyApplicationStatus) error {
+	if reflect.DeepEqual(previous, &cr.Status) {
+		return nil
+	}
+	err := r.client.Status().Update(context.TODO(), cr)
+	if err != nil {
+		log.Printf("Failed to update WildflyApplication status: %v\n", err)
+	}
+	return err
+}
+
+// desiredArtifact returns the artifact defined in the spec
+func desiredArtifact(cr *wildflyv1alpha1.WildflyApplication) (*wildflyv1alpha1.WildflyArtifact, error) {
+	name := cr.Spec.DeploymentName
+	if name == "" {
+		u, err := url.Parse(cr.Spec.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid url %q: %v", cr.Spec.URL, err)
+		}
+		name = path.Base(u.Path)
+	}
+	if name == "" || name == "." || name == "/" {
+		return nil, fmt.Errorf("cannot infer the deployment name from url %q", cr.Spec.URL)
+	}
+	return &wildflyv1alpha1.WildflyArtifact{
+		DeploymentName: name,
+		URL:            cr.Spec.URL,
+		SHA256:         strings.ToLower(cr.Spec.Sha256),
+		Version:        cr.Spec.Version,
+	}, nil
+}
+
+// sameArtifact reports whether two artifacts are the same version, regardless of the hash
+// computed once deployed
+func sameArtifact(a, b *wildflyv1alpha1.WildflyArtifact) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.DeploymentName == b.DeploymentName && a.URL == b.URL && a.SHA256 == b.SHA256 && a.Version == b.Version
+}
+
+// deploy converges the servers towards the desired artifact. A new artifact is deployed on
+// every server; when it fails on any of them and rollback is enabled, the previous artifact is
+// deployed back and the failed one is not retried until the spec changes. Servers missing the
+// current artifact, e.g. restarted pods, get it again.
+func (r *ReconcileWildflyApplication) deploy(cr *wildflyv1alpha1.WildflyApplication, clients map[string]*management.Client) error {
+	desired, err := desiredArtifact(cr)
+	if err != nil {
+		return err
+	}
+	status := &cr.Status
+	if status.Failed != nil && !sameArtifact(status.Failed, desired) {
+		status.Failed = nil
+	}
+
+	if status.Failed == nil && !sameArtifact(status.Current, desired) {
+		previous := status.Current
+		err = r.deployArtifact(desired, clients)
+		if err == nil {
+			err = r.checkServers(cr, desired, clients)
+		}
+		if err == nil {
+			if previous != nil && previous.DeploymentName != desired.DeploymentName {
+				r.undeploy(previous.DeploymentName, clients)
+			}
+			status.Previous = previous
+			status.Current = desired
+			status.Phase = wildflyv1alpha1.ApplicationDeployed
+			status.Message = ""
+			return nil
+		}
+		if !cr.Spec.RollbackOnFailure || previous == nil {
+			status.Failed = desired
+			return err
+		}
+
+		log.Printf("Rolling back WildflyApplication %s/%s to %s: %v\n", cr.Namespace, cr.Name, previous.URL, err)
+		status.Failed = desired
+		if desired.DeploymentName != previous.DeploymentName {
+			r.undeploy(desired.DeploymentName, clients)
+		}
+		rollbackErr := r.deployArtifact(previous, clients)
+		if rollbackErr != nil {
+			return fmt.Errorf("rollback after %v failed: %v", err, rollbackErr)
+		}
+		status.Phase = wildflyv1alpha1.ApplicationRolledBack
+		status.Message = err.Error()
+		return r.checkServers(cr, previous, clients)
+	}
+
+	if status.Current == nil {
+		return fmt.Errorf("artifact %s failed to deploy", desired.URL)
+	}
+	// Redeploy the current artifact on the servers missing it
+	err = r.deployArtifact(status.Current, clients)
+	if err != nil {
+		return err
+	}
+	err = r.checkServers(cr, status.Current, clients)
+	if err != nil {
+		return err
+	}
+	if status.Failed == nil {
+		status.Phase = wildflyv1alpha1.ApplicationDeployed
+		status.Message = ""
+	}
+	return nil
+}
+
+// deployArtifact deploys the artifact on the servers which do not have its content deployed
+// yet. The artifact is only downloaded when at least one server needs it, and its hash is
+// recorded in the artifact.
+func (r *ReconcileWildflyApplication) deployArtifact(artifact *wildflyv1alpha1.WildflyArtifact, clients map[string]*management.Client) error {
+	var content []byte
+	for _, pod := range servers.PodNames(clients) {
+		c := clients[pod]
+		if artifact.Hash != "" {
+			hash, err := c.DeploymentContentHash(artifact.DeploymentName)
+			if err == nil && hex.EncodeToString(hash) == artifact.Hash {
+				continue
+			}
+		}
+		if content == nil {
+			var err error
+			content, err = r.download(artifact)
+			if err != nil {
+				return err
+			}
+			sum := sha1.Sum(content)
+			artifact.Hash = hex.EncodeToString(sum[:])
+		}
+
+		log.Printf("Deploying %s on pod %s\n", artifact.DeploymentName, pod)
+		hash, err := c.UploadContent(artifact.DeploymentName, content)
+		if err != nil {
+			return fmt.Errorf("failed to upload %s to pod %s: %v", artifact.DeploymentName, pod, err)
+		}
+		err = c.Deploy(artifact.DeploymentName, hash)
+		if err != nil {
+			return fmt.Errorf("failed to deploy %s on pod %s: %v", artifact.DeploymentName, pod, err)
+		}
+	}
+	return nil
+}
+
+// checkServers records the status of the deployment on each server and returns an error when
+// it failed on any of them
+func (r *ReconcileWildflyApplication) checkServers(cr *wildflyv1alpha1.WildflyApplication, artifact *wildflyv1alpha1.WildflyArtifact,
+	clients map[string]*management.Client) error {
+	cr.Status.Servers = nil
+	var failed []string
+	for _, pod := range servers.PodNames(clients) {
+		server := wildflyv1alpha1.WildflyApplicationServer{Pod: pod}
+		status, err := clients[pod].DeploymentStatus(artifact.DeploymentName)
+		switch {
+		case management.IsNotFound(err):
+			server.Status = notDeployed
+		case err != nil:
+			server.Status = notDeployed
+			server.Message = err.Error()
+		default:
+			server.Status = status
+		}
+		if server.Status == management.DeploymentStatusFailed {
+			failed = append(failed, pod)
+		}
+		cr.Status.Servers = append(cr.Status.Servers, server)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%s failed to deploy on %s", artifact.DeploymentName, strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// undeploy removes a deployment from the servers, failures are only logged
+func (r *ReconcileWildflyApplication) undeploy(name string, clients map[string]*management.Client) {
+	for _, pod := range servers.PodNames(clients) {
+		log.Printf("Undeploying %s from pod %s\n", name, pod)
+		if err := clients[pod].Undeploy(name); err != nil {
+			log.Printf("Failed to undeploy %s from pod %s: %v\n", name, pod, err)
+		}
+	}
+}
+
+// download fetches an artifact and verifies its checksum
+func (r *ReconcileWildflyApplication) download(artifact *wildflyv1alpha1.WildflyArtifact) ([]byte, error) {
+	log.Printf("Downloading %s\n", artifact.URL)
+	resp, err := r.httpClient.Get(artifact.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %v", artifact.URL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: HTTP %d", artifact.URL, resp.StatusCode)
+	}
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %v", artifact.URL, err)
+	}
+	if artifact.SHA256 != "" {
+		sum := sha256.Sum256(content)
+		if hex.EncodeToString(sum[:]) != artifact.SHA256 {
+			return nil, fmt.Errorf("checksum mismatch for %s", artifact.URL)
+		}
+	}
+	return content, nil
+}
+
+// finalize undeploys the application from the servers and removes the finalizer. The
+// application is left in place when the Wildfly cannot be reached, e.g. because it is
+// being deleted too.
+func (r *ReconcileWildflyApplication) finalize(cr *wildflyv1alpha1.WildflyApplication) error {
+	if !hasFinalizer(cr) {
+		return nil
+	}
+	if cr.Status.Current != nil {
+		clients, err := r.managementClients(cr)
+		if err != nil {
+			log.Printf("Cannot undeploy WildflyApplication %s/%s: %v\n", cr.Namespace, cr.Name, err)
+		} else {
+			r.undeploy(cr.Status.Current.DeploymentName, clients)
+		}
+	}
+
+	var finalizers []string
+	for _, f := range cr.Finalizers {
+		if f != undeployFinalizer {
+			finalizers = append(finalizers, f)
+		}
+	}
+	cr.Finalizers = finalizers
+	err := r.client.Update(context.TODO(), cr)
+	if err != nil {
+		log.Printf("Failed to remove finalizer from WildflyApplication: %v\n", err)
+	}
+	return err
+}
+
+// hasFinalizer reports whether the undeploy finalizer is set on the application
+func hasFinalizer(cr *wildflyv1alpha1.WildflyApplication) bool {
+	for _, f := range cr.Finalizers {
+		if f == undeployFinalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// managementClients returns a management client for each ready pod of the Wildfly referenced
+// by the application, keyed by pod name
+func (r *ReconcileWildflyApplication) managementClients(cr *wildflyv1alpha1.WildflyApplication) (map[string]*management.Client, error) {
+	wildfly := &wildflyv1alpha1.Wildfly{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: cr.Spec.Wildfly, Namespace: cr.Namespace}, wildfly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Wildfly %s: %v", cr.Spec.Wildfly, err)
+	}
+	return servers.ManagementClients(r.client, wildfly)
+}
@@ -0,0 +1,74 @@
+package wildflyapplication
+
+import (
+	"reflect"
+	"testing"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+)
+
+func TestDesiredArtifact(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    wildflyv1alpha1.WildflyApplicationSpec
+		want    *wildflyv1alpha1.WildflyArtifact
+		wantErr bool
+	}{
+		{
+			name: "name from the url",
+			spec: wildflyv1alpha1.WildflyApplicationSpec{URL: "https://repo.example.com/app/1.0/app-1.0.war?token=x", Version: "1.0"},
+			want: &wildflyv1alpha1.WildflyArtifact{DeploymentName: "app-1.0.war",
+				URL: "https://repo.example.com/app/1.0/app-1.0.war?token=x", Version: "1.0"},
+		},
+		{
+			name: "explicit name and lowercased hash",
+			spec: wildflyv1alpha1.WildflyApplicationSpec{URL: "https://repo.example.com/download", DeploymentName: "app.war", Sha256: "ABCDEF"},
+			want: &wildflyv1alpha1.WildflyArtifact{DeploymentName: "app.war", URL: "https://repo.example.com/download", SHA256: "abcdef"},
+		},
+		{
+			name:    "url without path",
+			spec:    wildflyv1alpha1.WildflyApplicationSpec{URL: "https://repo.example.com/"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid url",
+			spec:    wildflyv1alpha1.WildflyApplicationSpec{URL: "http://[::1"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		cr := &wildflyv1alpha1.WildflyApplication{Spec: tt.spec}
+		got, err := desiredArtifact(cr)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: got error %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got artifact %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSameArtifact(t *testing.T) {
+	artifact := &wildflyv1alpha1.WildflyArtifact{DeploymentName: "app.war", URL: "https://repo.example.com/app.war", Version: "1.0"}
+	deployed := *artifact
+	deployed.Hash = "0123"
+	upgraded := *artifact
+	upgraded.Version = "1.1"
+
+	tests := []struct {
+		name string
+		a, b *wildflyv1alpha1.WildflyArtifact
+		want bool
+	}{
+		{"both nil", nil, nil, true},
+		{"one nil", artifact, nil, false},
+		{"hash ignored", artifact, &deployed, true},
+		{"other version", artifact, &upgraded, false},
+	}
+	for _, tt := range tests {
+		if got := sameArtifact(tt.a, tt.b); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,393 @@
+package wildflydatasource
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"reflect"
+	"time"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+	"github.com/giannisalinetti/wildfly-operator/pkg/controller/servers"
+	"github.com/giannisalinetti/wildfly-operator/pkg/management"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	// removeFinalizer makes the operator remove the datasource from the servers before the
+	// resource is deleted
+	removeFinalizer = "wildfly.extraordy.com/remove-datasource"
+	// credentialsAnnotation records the hash of the credentials written to the servers, since
+	// the password read back from the servers cannot be compared with the desired one
+	credentialsAnnotation = "wildfly.extraordy.com/credentials-hash"
+	passwordAttribute     = "password"
+	// pollInterval is how often the datasources and their connections are checked, e.g. on
+	// servers restarted with their initial configuration
+	pollInterval = 30 * time.Second
+)
+
+// optionalAttributes are the attributes of the datasource undefined when they are not set in
+// the spec anymore
+var optionalAttributes = []string{"min-pool-size", "max-pool-size", "user-name"}
+
+// Add creates a new WildflyDatasource Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &ReconcileWildflyDatasource{client: mgr.GetClient(), scheme: mgr.GetScheme()}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New("wildflydatasource-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to primary resource WildflyDatasource
+	err = c.Watch(&source.Kind{Type: &wildflyv1alpha1.WildflyDatasource{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to the pods of a Wildfly and requeue the datasources targeting it, so
+	// that restarted or added servers get the datasources
+	err = c.Watch(&source.Kind{Type: &corev1.Pod{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: wildflyMapper(mgr.GetClient(), func(obj handler.MapObject) string {
+			return obj.Meta.GetLabels()["app"]
+		}),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to the Wildfly resources and requeue the datasources targeting them
+	err = c.Watch(&source.Kind{Type: &wildflyv1alpha1.Wildfly{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: wildflyMapper(mgr.GetClient(), func(obj handler.MapObject) string {
+			return obj.Meta.GetName()
+		}),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to the credentials Secrets and requeue the datasources reading them,
+	// so that rotated credentials are written to the servers
+	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: datasourceMapper(mgr.GetClient(), func(ds *wildflyv1alpha1.WildflyDatasource, obj handler.MapObject) bool {
+			return ds.Spec.CredentialsSecret != "" && ds.Spec.CredentialsSecret == obj.Meta.GetName()
+		}),
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// wildflyMapper returns a Mapper that enqueues the datasources of the namespace of the event
+// object targeting the Wildfly named by the wildfly function
+func wildflyMapper(c client.Client, wildfly func(handler.MapObject) string) handler.Mapper {
+	return datasourceMapper(c, func(ds *wildflyv1alpha1.WildflyDatasource, obj handler.MapObject) bool {
+		name := wildfly(obj)
+		if name == "" {
+			return false
+		}
+		for _, w := range ds.Spec.Wildflies {
+			if w == name {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// datasourceMapper returns a Mapper that enqueues the datasources of the namespace of the
+// event object which reference it, according to the references function
+func datasourceMapper(c client.Client, references func(*wildflyv1alpha1.WildflyDatasource, handler.MapObject) bool) handler.Mapper {
+	return handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+		dsList := &wildflyv1alpha1.WildflyDatasourceList{}
+		err := c.List(context.TODO(), client.InNamespace(obj.Meta.GetNamespace()), dsList)
+		if err != nil {
+			log.Printf("Failed to list WildflyDatasource resources: %v\n", err)
+			return nil
+		}
+		requests := []reconcile.Request{}
+		for i := range dsList.Items {
+			if references(&dsList.Items[i], obj) {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+					Name:      dsList.Items[i].Name,
+					Namespace: dsList.Items[i].Namespace,
+				}})
+			}
+		}
+		return requests
+	})
+}
+
+var _ reconcile.Reconciler = &ReconcileWildflyDatasource{}
+
+// ReconcileWildflyDatasource reconciles a WildflyDatasource object
+type ReconcileWildflyDatasource struct {
+	// This client, initialized using mgr.Client() above, is a split client
+	// that reads objects from the cache and writes to the apiserver
+	client client.Client
+	scheme *runtime.Scheme
+}
+
+// Reconcile creates or updates the datasource of a WildflyDatasource on every running server
+// of the targeted Wildfly resources through the management interface, tests a connection of
+// its pool on each of them and removes the datasource when the resource is deleted.
+func (r *ReconcileWildflyDatasource) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	log.Printf("Reconciling WildflyDatasource %s/%s\n", request.Namespace, request.Name)
+
+	// Fetch the WildflyDatasource instance
+	instance := &wildflyv1alpha1.WildflyDatasource{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, instance)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+	previousStatus := instance.Status.DeepCopy()
+
+	if instance.DeletionTimestamp != nil {
+		return reconcile.Result{}, r.finalize(instance)
+	}
+	if !hasFinalizer(instance) {
+		instance.Finalizers = append(instance.Finalizers, removeFinalizer)
+		err = r.client.Update(context.TODO(), instance)
+		if err != nil {
+			log.Printf("Failed to add finalizer to WildflyDatasource: %v\n", err)
+		}
+		return reconcile.Result{Requeue: true}, err
+	}
+
+	instance.Status.Servers = nil
+	attributes, err := r.datasourceAttributes(instance)
+	if err != nil {
+		log.Printf("Failed to define WildflyDatasource %s/%s: %v\n", instance.Namespace, instance.Name, err)
+	}
+	hash := credentialsHash(attributes)
+	writeCredentials := instance.Annotations[credentialsAnnotation] != hash
+	applied := err == nil
+	for _, name := range instance.Spec.Wildflies {
+		clients, clientsErr := r.managementClients(instance, name)
+		if clientsErr != nil {
+			instance.Status.Servers = append(instance.Status.Servers, wildflyv1alpha1.WildflyDatasourceServer{
+				Wildfly: name,
+				Message: clientsErr.Error(),
+			})
+			applied = false
+			continue
+		}
+		for _, pod := range servers.PodNames(clients) {
+			server := wildflyv1alpha1.WildflyDatasourceServer{Wildfly: name, Pod: pod}
+			if err != nil {
+				server.Message = err.Error()
+			} else if applyErr := r.apply(instance, attributes, writeCredentials, clients[pod]); applyErr != nil {
+				server.Message = applyErr.Error()
+				applied = false
+			} else {
+				server.Connected, server.Message = testConnection(instance, clients[pod])
+			}
+			instance.Status.Servers = append(instance.Status.Servers, server)
+		}
+	}
+
+	if !reflect.DeepEqual(previousStatus, &instance.Status) {
+		err = r.client.Status().Update(context.TODO(), instance)
+		if err != nil {
+			log.Printf("Failed to update WildflyDatasource status: %v\n", err)
+			return reconcile.Result{}, err
+		}
+	}
+
+	// The credentials are written again until every server got them
+	if writeCredentials && applied {
+		if instance.Annotations == nil {
+			instance.Annotations = map[string]string{}
+		}
+		instance.Annotations[credentialsAnnotation] = hash
+		err = r.client.Update(context.TODO(), instance)
+		if err != nil {
+			log.Printf("Failed to record the credentials of WildflyDatasource: %v\n", err)
+			return reconcile.Result{}, err
+		}
+	}
+	return reconcile.Result{RequeueAfter: pollInterval}, nil
+}
+
+// datasourceName returns the name of the datasource on the servers
+func datasourceName(cr *wildflyv1alpha1.WildflyDatasource) string {
+	if cr.Spec.Name != "" {
+		return cr.Spec.Name
+	}
+	return cr.Name
+}
+
+// datasourceAttributes returns the attributes of the datasource defined in the spec, with the
+// credentials read from its Secret
+func (r *ReconcileWildflyDatasource) datasourceAttributes(cr *wildflyv1alpha1.WildflyDatasource) (map[string]interface{}, error) {
+	spec := cr.Spec.WildflyDatasourceDefinition
+	if spec.JNDIName == "" || spec.Driver == "" || spec.ConnectionURL == "" {
+		return nil, fmt.Errorf("jndiName, driver and connectionURL must be set")
+	}
+	if spec.MaxPoolSize > 0 && spec.MinPoolSize > spec.MaxPoolSize {
+		return nil, fmt.Errorf("minPoolSize %d is greater than maxPoolSize %d", spec.MinPoolSize, spec.MaxPoolSize)
+	}
+	attributes := map[string]interface{}{
+		"jndi-name":      spec.JNDIName,
+		"driver-name":    spec.Driver,
+		"connection-url": spec.ConnectionURL,
+		"enabled":        true,
+	}
+	if spec.MinPoolSize > 0 {
+		attributes["min-pool-size"] = spec.MinPoolSize
+	}
+	if spec.MaxPoolSize > 0 {
+		attributes["max-pool-size"] = spec.MaxPoolSize
+	}
+	if spec.CredentialsSecret != "" {
+		secret := &corev1.Secret{}
+		err := r.client.Get(context.TODO(), types.NamespacedName{Name: spec.CredentialsSecret, Namespace: cr.Namespace}, secret)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get credentials Secret %s: %v", spec.CredentialsSecret, err)
+		}
+		attributes["user-name"] = string(secret.Data[corev1.BasicAuthUsernameKey])
+		attributes["password"] = string(secret.Data[corev1.BasicAuthPasswordKey])
+	}
+	return attributes, nil
+}
+
+// credentialsHash returns the hash of the user name and password of the datasource, empty
+// when the datasource has no credentials
+func credentialsHash(attributes map[string]interface{}) string {
+	password, ok := attributes[passwordAttribute]
+	if !ok {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprint(attributes["user-name"], "\x00", password)))
+	return hex.EncodeToString(sum[:])
+}
+
+// apply adds the datasource to a server or updates the attributes which changed. The password
+// is only written when writeCredentials is set.
+func (r *ReconcileWildflyDatasource) apply(cr *wildflyv1alpha1.WildflyDatasource, attributes map[string]interface{}, writeCredentials bool, c *management.Client) error {
+	name := datasourceName(cr)
+	found, err := c.ReadDatasource(name)
+	switch {
+	case management.IsNotFound(err):
+		log.Printf("Adding datasource %s on %s\n", name, c.URL)
+		return c.AddDatasource(name, attributes)
+	case err == nil:
+		return c.UpdateDatasource(name, changedAttributes(found, attributes, writeCredentials))
+	}
+	return err
+}
+
+// testConnection tests a connection of the pool of the datasource. It returns whether the
+// connection succeeded and the failure message.
+func testConnection(cr *wildflyv1alpha1.WildflyDatasource, c *management.Client) (bool, string) {
+	err := c.TestConnection(datasourceName(cr))
+	if err != nil {
+		return false, err.Error()
+	}
+	return true, ""
+}
+
+// changedAttributes returns the desired attributes which differ from the found ones, and the
+// optional attributes to undefine. Values are compared on their text since the numbers are
+// decoded as floats. The password is not compared, it is written, or undefined, when
+// writeCredentials is set.
+func changedAttributes(found, desired map[string]interface{}, writeCredentials bool) map[string]interface{} {
+	changed := map[string]interface{}{}
+	for k, v := range desired {
+		if k != passwordAttribute && fmt.Sprint(found[k]) != fmt.Sprint(v) {
+			changed[k] = v
+		}
+	}
+	if writeCredentials {
+		changed[passwordAttribute] = desired[passwordAttribute]
+	}
+	for _, k := range optionalAttributes {
+		if _, ok := desired[k]; !ok && found[k] != nil {
+			changed[k] = nil
+		}
+	}
+	return changed
+}
+
+// managementClients returns a management client for each ready pod of the named Wildfly,
+// keyed by pod name
+func (r *ReconcileWildflyDatasource) managementClients(cr *wildflyv1alpha1.WildflyDatasource, name string) (map[string]*management.Client, error) {
+	wildfly := &wildflyv1alpha1.Wildfly{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: cr.Namespace}, wildfly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Wildfly %s: %v", name, err)
+	}
+	return servers.ManagementClients(r.client, wildfly)
+}
+
+// finalize removes the datasource from the servers and removes the finalizer. The datasource
+// is left in place on the Wildfly resources which cannot be reached, e.g. because they are
+// being deleted too.
+func (r *ReconcileWildflyDatasource) finalize(cr *wildflyv1alpha1.WildflyDatasource) error {
+	if !hasFinalizer(cr) {
+		return nil
+	}
+	name := datasourceName(cr)
+	for _, wildfly := range cr.Spec.Wildflies {
+		clients, err := r.managementClients(cr, wildfly)
+		if err != nil {
+			log.Printf("Cannot remove WildflyDatasource %s/%s from Wildfly %s: %v\n", cr.Namespace, cr.Name, wildfly, err)
+			continue
+		}
+		for _, pod := range servers.PodNames(clients) {
+			log.Printf("Removing datasource %s from pod %s\n", name, pod)
+			if err := clients[pod].RemoveDatasource(name); err != nil {
+				log.Printf("Failed to remove datasource %s from pod %s: %v\n", name, pod, err)
+			}
+		}
+	}
+
+	var finalizers []string
+	for _, f := range cr.Finalizers {
+		if f != removeFinalizer {
+			finalizers = append(finalizers, f)
+		}
+	}
+	cr.Finalizers = finalizers
+	err := r.client.Update(context.TODO(), cr)
+	if err != nil {
+		log.Printf("Failed to remove finalizer from WildflyDatasource: %v\n", err)
+	}
+	return err
+}
+
+// hasFinalizer reports whether the remove finalizer is set on the datasource
+func hasFinalizer(cr *wildflyv1alpha1.WildflyDatasource) bool {
+	for _, f := range cr.Finalizers {
+		if f == removeFinalizer {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package wildflydatasource
+
+import (
+	"reflect"
+	"testing"
+
+	wildflyv1alpha1 "github.com/giannisalinetti/wildfly-operator/pkg/apis/wildfly/v1alpha1"
+)
+
+func TestChangedAttributes(t *testing.T) {
+	desired := map[string]interface{}{
+		"jndi-name":      "java:/ExampleDS",
+		"connection-url": "jdbc:h2:mem:test",
+		"max-pool-size":  int32(20),
+		"user-name":      "sa",
+		"password":       "secret",
+	}
+
+	tests := []struct {
+		name             string
+		found            map[string]interface{}
+		desired          map[string]interface{}
+		writeCredentials bool
+		want             map[string]interface{}
+	}{
+		{
+			name: "unchanged, numbers decoded as floats",
+			found: map[string]interface{}{"jndi-name": "java:/ExampleDS", "connection-url": "jdbc:h2:mem:test",
+				"max-pool-size": float64(20), "user-name": "sa", "password": nil},
+			desired: desired,
+			want:    map[string]interface{}{},
+		},
+		{
+			name: "changed url and credentials written",
+			found: map[string]interface{}{"jndi-name": "java:/ExampleDS", "connection-url": "jdbc:h2:mem:old",
+				"max-pool-size": float64(20), "user-name": "sa"},
+			desired:          desired,
+			writeCredentials: true,
+			want:             map[string]interface{}{"connection-url": "jdbc:h2:mem:test", "password": "secret"},
+		},
+		{
+			name: "optional attributes undefined",
+			found: map[string]interface{}{"jndi-name": "java:/ExampleDS", "connection-url": "jdbc:h2:mem:test",
+				"min-pool-size": float64(5), "max-pool-size": float64(20), "user-name": "sa"},
+			desired:          map[string]interface{}{"jndi-name": "java:/ExampleDS", "connection-url": "jdbc:h2:mem:test"},
+			writeCredentials: true,
+			want:             map[string]interface{}{"min-pool-size": nil, "max-pool-size": nil, "user-name": nil, "password": nil},
+		},
+	}
+	for _, tt := range tests {
+		if got := changedAttributes(tt.found, tt.desired, tt.writeCredentials); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got attributes %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCredentialsHash(t *testing.T) {
+	withPassword := func(user, password string) map[string]interface{} {
+		return map[string]interface{}{"user-name": user, "password": password}
+	}
+	if got := credentialsHash(map[string]interface{}{"jndi-name": "java:/ExampleDS"}); got != "" {
+		t.Errorf("got hash %q without credentials, want none", got)
+	}
+	if credentialsHash(withPassword("sa", "a")) != credentialsHash(withPassword("sa", "a")) {
+		t.Errorf("got different hashes for the same credentials")
+	}
+	if credentialsHash(withPassword("sa", "a")) == credentialsHash(withPassword("sa", "b")) {
+		t.Errorf("got the same hash for different passwords")
+	}
+	if credentialsHash(withPassword("sa", "a")) == credentialsHash(withPassword("s", "aa")) {
+		t.Errorf("got the same hash for different user names")
+	}
+}
+
+func TestDatasourceAttributes(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    wildflyv1alpha1.WildflyDatasourceDefinition
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name:    "missing connection url",
+			spec:    wildflyv1alpha1.WildflyDatasourceDefinition{JNDIName: "java:/ExampleDS", Driver: "h2"},
+			wantErr: true,
+		},
+		{
+			name: "minimum over maximum",
+			spec: wildflyv1alpha1.WildflyDatasourceDefinition{JNDIName: "java:/ExampleDS", Driver: "h2",
+				ConnectionURL: "jdbc:h2:mem:test", MinPoolSize: 20, MaxPoolSize: 5},
+			wantErr: true,
+		},
+		{
+			name: "pool bounds",
+			spec: wildflyv1alpha1.WildflyDatasourceDefinition{JNDIName: "java:/ExampleDS", Driver: "h2",
+				ConnectionURL: "jdbc:h2:mem:test", MinPoolSize: 5, MaxPoolSize: 20},
+			want: map[string]interface{}{"jndi-name": "java:/ExampleDS", "driver-name": "h2",
+				"connection-url": "jdbc:h2:mem:test", "enabled": true, "min-pool-size": int32(5), "max-pool-size": int32(20)},
+		},
+		{
+			name: "minimum without maximum",
+			spec: wildflyv1alpha1.WildflyDatasourceDefinition{JNDIName: "java:/ExampleDS", Driver: "h2",
+				ConnectionURL: "jdbc:h2:mem:test", MinPoolSize: 5},
+			want: map[string]interface{}{"jndi-name": "java:/ExampleDS", "driver-name": "h2",
+				"connection-url": "jdbc:h2:mem:test", "enabled": true, "min-pool-size": int32(5)},
+		},
+	}
+	for _, tt := range tests {
+		cr := &wildflyv1alpha1.WildflyDatasource{}
+		cr.Spec.WildflyDatasourceDefinition = tt.spec
+		r := &ReconcileWildflyDatasource{}
+		got, err := r.datasourceAttributes(cr)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: got error %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got attributes %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+// Package management implements a client for the HTTP management interface of WildFly.
+// Operations are sent as DMR JSON documents to the /management endpoint, which listens on
+// port 9990 by default and is protected by digest authentication against the users of
+// the ManagementRealm.
+package management
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultPort is the port of the HTTP management interface
+const DefaultPort = 9990
+
+const defaultTimeout = 10 * time.Second
+
+// Client executes management operations against a single server
+type Client struct {
+	// URL is the management endpoint, e.g. http://10.0.0.1:9990/management
+	URL      string
+	Username string
+	Password string
+	// HTTPClient is used to send the requests, it can be replaced to tune timeouts or
+	// the transport.
+	HTTPClient *http.Client
+}
+
+// NewClient returns a Client for the given management endpoint and credentials
+func NewClient(endpoint, username, password string) *Client {
+	return &Client{
+		URL:        endpoint,
+		Username:   username,
+		Password:   password,
+		HTTPClient: &http.Client{Timeout: defaultTimeout},
+	}
+}
+
+// NewClientForHost returns a Client for the management interface of a host listening
+// on the default port.
+func NewClientForHost(host, username, password string) *Client {
+	return NewClient(fmt.Sprintf("http://%s:%d/management", host, DefaultPort), username, password)
+}
+
+// Execute sends an operation to the server and returns its result. A failed outcome is
+// returned as an *OperationError along with the result.
+func (c *Client) Execute(op Operation) (*Result, error) {
+	body, err := json.Marshal(op)
+	if err != nil {
+		return nil, err
+	}
+	return c.send(c.URL, "application/json", body, op.Name)
+}
+
+// send posts a body to an endpoint of the management interface, authenticating when
+// challenged, and decodes the result of the operation.
+func (c *Client) send(endpoint, contentType string, body []byte, opName string) (*Result, error) {
+	resp, err := c.post(endpoint, contentType, body, "")
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		authorization, err := c.authorization(endpoint, challenge)
+		if err != nil {
+			return nil, err
+		}
+		resp, err = c.post(endpoint, contentType, body, authorization)
+		if err != nil {
+			return nil, err
+		}
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("management authentication failed for user %q", c.Username)
+	}
+
+	// Failed operations are reported with a 500 status code and a regular result body
+	result := &Result{}
+	if err := json.Unmarshal(data, result); err != nil {
+		return nil, fmt.Errorf("unexpected management response (HTTP %d): %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if !result.Success() {
+		return result, &OperationError{Operation: opName, Description: failureDescription(result)}
+	}
+	return result, nil
+}
+
+// ReadAttribute reads an attribute and decodes its value into v
+func (c *Client) ReadAttribute(address Address, name string, v interface{}) error {
+	result, err := c.Execute(ReadAttribute(address, name))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(result.Result, v)
+}
+
+// ReadResource reads a resource and decodes its attributes into v
+func (c *Client) ReadResource(address Address, recursive, includeRuntime bool, v interface{}) error {
+	result, err := c.Execute(ReadResource(address, recursive, includeRuntime))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(result.Result, v)
+}
+
+// ExecuteComposite executes the steps atomically and returns the result of each step in
+// order. If any step fails the whole composite is rolled back and an error is returned.
+func (c *Client) ExecuteComposite(steps ...Operation) ([]Result, error) {
+	result, err := c.Execute(Composite(steps...))
+	if err != nil {
+		return nil, err
+	}
+	stepResults := map[string]Result{}
+	if err := json.Unmarshal(result.Result, &stepResults); err != nil {
+		return nil, err
+	}
+	results := make([]Result, len(steps))
+	for i := range steps {
+		results[i] = stepResults[fmt.Sprintf("step-%d", i+1)]
+	}
+	return results, nil
+}
+
+// post sends a body to an endpoint of the management interface with an optional
+// Authorization header
+func (c *Client) post(endpoint, contentType string, body []byte, authorization string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Accept", "application/json")
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return httpClient.Do(req)
+}
+
+// authorization computes the Authorization header answering a Basic or Digest challenge
+// for a request to the endpoint
+func (c *Client) authorization(endpoint, challenge string) (string, error) {
+	switch {
+	case strings.HasPrefix(challenge, "Digest "):
+		return c.digestAuthorization(endpoint, parseChallenge(strings.TrimPrefix(challenge, "Digest ")))
+	case strings.HasPrefix(challenge, "Basic "):
+		req, _ := http.NewRequest(http.MethodPost, endpoint, nil)
+		req.SetBasicAuth(c.Username, c.Password)
+		return req.Header.Get("Authorization"), nil
+	}
+	return "", fmt.Errorf("unsupported management authentication challenge %q", challenge)
+}
+
+// digestAuthorization implements RFC 2617 digest authentication with MD5 and qop=auth,
+// as required by the ManagementRealm of WildFly.
+func (c *Client) digestAuthorization(endpoint string, params map[string]string) (string, error) {
+	if alg, ok := params["algorithm"]; ok && !strings.EqualFold(alg, "MD5") {
+		return "", fmt.Errorf("unsupported digest algorithm %q", alg)
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	uri := u.RequestURI()
+
+	ha1 := md5Hex(c.Username + ":" + params["realm"] + ":" + c.Password)
+	ha2 := md5Hex(http.MethodPost + ":" + uri)
+
+	cnonceBytes := make([]byte, 8)
+	if _, err := rand.Read(cnonceBytes); err != nil {
+		return "", err
+	}
+	cnonce := hex.EncodeToString(cnonceBytes)
+	nc := "00000001"
+
+	var response string
+	qop := ""
+	if strings.Contains(params["qop"], "auth") {
+		qop = "auth"
+		response = md5Hex(strings.Join([]string{ha1, params["nonce"], nc, cnonce, qop, ha2}, ":"))
+	} else {
+		response = md5Hex(ha1 + ":" + params["nonce"] + ":" + ha2)
+	}
+
+	auth := fmt.Sprintf(`Digest username="%s", realm="%s", nonce="%s", uri="%s", response="%s"`,
+		c.Username, params["realm"], params["nonce"], uri, response)
+	if qop != "" {
+		auth += fmt.Sprintf(`, qop=%s, nc=%s, cnonce="%s"`, qop, nc, cnonce)
+	}
+	if opaque, ok := params["opaque"]; ok {
+		auth += fmt.Sprintf(`, opaque="%s"`, opaque)
+	}
+	if alg, ok := params["algorithm"]; ok {
+		auth += ", algorithm=" + alg
+	}
+	return auth, nil
+}
+
+// parseChallenge parses the comma separated key=value parameters of a challenge
+func parseChallenge(s string) map[string]string {
+	params := map[string]string{}
+	for _, part := range splitChallenge(s) {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		params[strings.ToLower(kv[0])] = strings.Trim(kv[1], `"`)
+	}
+	return params
+}
+
+// splitChallenge splits on commas that are not inside quoted values
+func splitChallenge(s string) []string {
+	parts := []string{}
+	quoted := false
+	start := 0
+	for i, ch := range s {
+		switch {
+		case ch == '"':
+			quoted = !quoted
+		case ch == ',' && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// failureDescription returns the failure description of a result as a plain string
+func failureDescription(result *Result) string {
+	if len(result.FailureDescription) == 0 {
+		return "unknown failure"
+	}
+	var s string
+	if err := json.Unmarshal(result.FailureDescription, &s); err == nil {
+		return s
+	}
+	return string(result.FailureDescription)
+}
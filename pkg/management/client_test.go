@@ -0,0 +1,268 @@
+package management
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const (
+	testUser     = "admin"
+	testPassword = "secret"
+	testRealm    = "ManagementRealm"
+	testNonce    = "dcd98b7102dd2f0e8b11d0f600bfb0c093"
+)
+
+// fakeServer is a management interface answering the operations with handle. When digest is
+// set, the requests must authenticate against the test user.
+type fakeServer struct {
+	*httptest.Server
+	t       *testing.T
+	digest  bool
+	handle  func(op map[string]interface{}) (int, string)
+	ops     []map[string]interface{}
+	answers int
+}
+
+func newFakeServer(t *testing.T, digest bool, handle func(op map[string]interface{}) (int, string)) *fakeServer {
+	s := &fakeServer{t: t, digest: digest, handle: handle}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+func (s *fakeServer) client(password string) *Client {
+	return NewClient(s.URL+"/management", testUser, password)
+}
+
+func (s *fakeServer) serve(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/management" {
+		s.t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	}
+	if s.digest && !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate",
+			fmt.Sprintf(`Digest realm="%s", nonce="%s", qop="auth", algorithm=MD5, opaque="00000000"`, testRealm, testNonce))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		s.t.Errorf("reading request: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	op := map[string]interface{}{}
+	if err := json.Unmarshal(body, &op); err != nil {
+		s.t.Errorf("request is not JSON: %v: %s", err, body)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	s.ops = append(s.ops, op)
+	status, response := s.handle(op)
+	s.answers++
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	fmt.Fprint(w, response)
+}
+
+// authorized checks the digest response of the request as computed by the server
+func (s *fakeServer) authorized(r *http.Request) bool {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Digest ") {
+		return false
+	}
+	params := parseChallenge(strings.TrimPrefix(header, "Digest "))
+	if params["username"] != testUser || params["realm"] != testRealm || params["nonce"] != testNonce ||
+		params["uri"] != r.URL.RequestURI() || params["opaque"] != "00000000" || params["qop"] != "auth" {
+		s.t.Errorf("unexpected digest parameters %v", params)
+		return false
+	}
+	ha1 := hexMD5(testUser + ":" + testRealm + ":" + testPassword)
+	ha2 := hexMD5(r.Method + ":" + params["uri"])
+	expected := hexMD5(strings.Join([]string{ha1, testNonce, params["nc"], params["cnonce"], "auth", ha2}, ":"))
+	return params["response"] == expected
+}
+
+func hexMD5(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestExecuteSuccess(t *testing.T) {
+	s := newFakeServer(t, false, func(op map[string]interface{}) (int, string) {
+		return http.StatusOK, `{"outcome": "success", "result": "running"}`
+	})
+	defer s.Close()
+
+	var state string
+	err := s.client(testPassword).ReadAttribute(NewAddress("subsystem", "undertow"), "server-state", &state)
+	if err != nil {
+		t.Fatalf("ReadAttribute: %v", err)
+	}
+	if state != "running" {
+		t.Errorf("got state %q, want running", state)
+	}
+
+	op := s.ops[0]
+	if op["operation"] != "read-attribute" || op["name"] != "server-state" {
+		t.Errorf("unexpected operation %v", op)
+	}
+	address, _ := json.Marshal(op["address"])
+	if string(address) != `[{"subsystem":"undertow"}]` {
+		t.Errorf("got address %s", address)
+	}
+}
+
+func TestExecuteRootAddress(t *testing.T) {
+	s := newFakeServer(t, false, func(op map[string]interface{}) (int, string) {
+		return http.StatusOK, `{"outcome": "success"}`
+	})
+	defer s.Close()
+
+	if err := s.client(testPassword).Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	address, _ := json.Marshal(s.ops[0]["address"])
+	if string(address) != `[]` {
+		t.Errorf("got address %s, want []", address)
+	}
+}
+
+func TestExecuteFailure(t *testing.T) {
+	s := newFakeServer(t, false, func(op map[string]interface{}) (int, string) {
+		return http.StatusInternalServerError, `{"outcome": "failed", "failure-description": ` +
+			`"WFLYCTL0216: Management resource '[(\"deployment\" => \"app.war\")]' not found", "rolled-back": true}`
+	})
+	defer s.Close()
+
+	result, err := s.client(testPassword).Execute(ReadResource(NewAddress("deployment", "app.war"), false, true))
+	opErr, ok := err.(*OperationError)
+	if !ok {
+		t.Fatalf("got error %v, want an *OperationError", err)
+	}
+	if opErr.Operation != "read-resource" || !strings.HasPrefix(opErr.Description, "WFLYCTL0216") {
+		t.Errorf("unexpected error %v", opErr)
+	}
+	if !IsNotFound(err) {
+		t.Errorf("IsNotFound(%v) = false", err)
+	}
+	if result == nil || result.Success() || !result.RolledBack {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestDigestAuthentication(t *testing.T) {
+	s := newFakeServer(t, true, func(op map[string]interface{}) (int, string) {
+		return http.StatusOK, `{"outcome": "success", "result": "NORMAL"}`
+	})
+	defer s.Close()
+
+	var mode string
+	err := s.client(testPassword).ReadAttribute(Address{}, "running-mode", &mode)
+	if err != nil {
+		t.Fatalf("ReadAttribute: %v", err)
+	}
+	if mode != "NORMAL" || s.answers != 1 {
+		t.Errorf("got mode %q after %d answers", mode, s.answers)
+	}
+}
+
+func TestDigestAuthenticationFailure(t *testing.T) {
+	s := newFakeServer(t, true, func(op map[string]interface{}) (int, string) {
+		t.Error("operation executed with a wrong password")
+		return http.StatusOK, `{"outcome": "success"}`
+	})
+	defer s.Close()
+
+	_, err := s.client("wrong").Execute(ReadAttribute(Address{}, "server-state"))
+	if err == nil || !strings.Contains(err.Error(), "authentication failed") {
+		t.Errorf("got error %v, want an authentication failure", err)
+	}
+}
+
+func TestUnauthorizedWithoutChallenge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	_, err := NewClient(server.URL+"/management", testUser, testPassword).Execute(ReadAttribute(Address{}, "server-state"))
+	if err == nil || !strings.Contains(err.Error(), "unsupported management authentication challenge") {
+		t.Errorf("got error %v, want an unsupported challenge", err)
+	}
+}
+
+func TestNonJSONResponse(t *testing.T) {
+	s := newFakeServer(t, false, func(op map[string]interface{}) (int, string) {
+		return http.StatusBadGateway, "<html>Bad Gateway</html>"
+	})
+	defer s.Close()
+
+	_, err := s.client(testPassword).Execute(ReadAttribute(Address{}, "server-state"))
+	if err == nil || !strings.Contains(err.Error(), "HTTP 502") || !strings.Contains(err.Error(), "Bad Gateway") {
+		t.Errorf("got error %v, want an unexpected response", err)
+	}
+}
+
+func TestExecuteComposite(t *testing.T) {
+	s := newFakeServer(t, false, func(op map[string]interface{}) (int, string) {
+		return http.StatusOK, `{"outcome": "success", "result": {` +
+			`"step-1": {"outcome": "success", "result": "running"},` +
+			`"step-2": {"outcome": "success", "result": "NORMAL"}}}`
+	})
+	defer s.Close()
+
+	results, err := s.client(testPassword).ExecuteComposite(
+		ReadAttribute(Address{}, "server-state"),
+		ReadAttribute(Address{}, "running-mode"),
+	)
+	if err != nil {
+		t.Fatalf("ExecuteComposite: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	for i, want := range []string{"running", "NORMAL"} {
+		var v string
+		if err := json.Unmarshal(results[i].Result, &v); err != nil || v != want || !results[i].Success() {
+			t.Errorf("step %d: got %s (%v), want %s", i+1, results[i].Result, err, want)
+		}
+	}
+
+	op := s.ops[0]
+	steps, ok := op["steps"].([]interface{})
+	if op["operation"] != "composite" || !ok || len(steps) != 2 {
+		t.Fatalf("unexpected composite %v", op)
+	}
+	if step := steps[1].(map[string]interface{}); step["operation"] != "read-attribute" || step["name"] != "running-mode" {
+		t.Errorf("unexpected step %v", step)
+	}
+}
+
+func TestExecuteCompositeFailure(t *testing.T) {
+	s := newFakeServer(t, false, func(op map[string]interface{}) (int, string) {
+		return http.StatusInternalServerError, `{"outcome": "failed", "result": {` +
+			`"step-1": {"outcome": "success"},` +
+			`"step-2": {"outcome": "failed", "failure-description": "WFLYCTL0201: Unknown attribute 'foo'"}},` +
+			`"failure-description": {"WFLYCTL0062: Composite operation failed and was rolled back. Steps that failed:": ` +
+			`{"Operation step-2": "WFLYCTL0201: Unknown attribute 'foo'"}}, "rolled-back": true}`
+	})
+	defer s.Close()
+
+	_, err := s.client(testPassword).ExecuteComposite(
+		WriteAttribute(Address{}, "name", "server"),
+		ReadAttribute(Address{}, "foo"),
+	)
+	opErr, ok := err.(*OperationError)
+	if !ok {
+		t.Fatalf("got error %v, want an *OperationError", err)
+	}
+	if opErr.Operation != "composite" || !strings.Contains(opErr.Description, "WFLYCTL0201") {
+		t.Errorf("unexpected error %v", opErr)
+	}
+}
@@ -0,0 +1,57 @@
+package management
+
+// DatasourceAddress returns the address of a datasource of the datasources subsystem
+func DatasourceAddress(name string) Address {
+	return NewAddress("subsystem", "datasources", "data-source", name)
+}
+
+// ReadDatasource returns the attributes of a datasource. The error satisfies IsNotFound when
+// the datasource does not exist.
+func (c *Client) ReadDatasource(name string) (map[string]interface{}, error) {
+	attributes := map[string]interface{}{}
+	err := c.ReadResource(DatasourceAddress(name), false, false, &attributes)
+	return attributes, err
+}
+
+// AddDatasource adds a datasource with the given attributes, it is started right away
+func (c *Client) AddDatasource(name string, attributes map[string]interface{}) error {
+	op := NewOperation("add", DatasourceAddress(name))
+	for k, v := range attributes {
+		op.Parameters[k] = v
+	}
+	_, err := c.Execute(op)
+	return err
+}
+
+// UpdateDatasource writes the given attributes of a datasource at once, a nil value undefines
+// the attribute. The datasource is restarted to apply them without reloading the server.
+func (c *Client) UpdateDatasource(name string, attributes map[string]interface{}) error {
+	var steps []Operation
+	for k, v := range attributes {
+		if v == nil {
+			steps = append(steps, UndefineAttribute(DatasourceAddress(name), k))
+		} else {
+			steps = append(steps, WriteAttribute(DatasourceAddress(name), k, v))
+		}
+	}
+	if len(steps) == 0 {
+		return nil
+	}
+	_, err := c.Execute(AllowResourceServiceRestart(Composite(steps...)))
+	return err
+}
+
+// RemoveDatasource removes a datasource, a missing datasource is not an error
+func (c *Client) RemoveDatasource(name string) error {
+	_, err := c.Execute(AllowResourceServiceRestart(NewOperation("remove", DatasourceAddress(name))))
+	if IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// TestConnection checks that a connection of the pool of a datasource can be obtained
+func (c *Client) TestConnection(name string) error {
+	_, err := c.Execute(NewOperation("test-connection-in-pool", DatasourceAddress(name)))
+	return err
+}
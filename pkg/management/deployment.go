@@ -0,0 +1,135 @@
+package management
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"mime/multipart"
+	"strings"
+)
+
+// Deployment statuses reported by the status attribute of a deployment resource
+const (
+	DeploymentStatusOK      = "OK"
+	DeploymentStatusFailed  = "FAILED"
+	DeploymentStatusStopped = "STOPPED"
+)
+
+// resourceNotFoundCode is the message code of the failures on a missing resource
+const resourceNotFoundCode = "WFLYCTL0216"
+
+// IsNotFound reports whether err is the failure of an operation on a missing resource
+func IsNotFound(err error) bool {
+	opErr, ok := err.(*OperationError)
+	return ok && strings.Contains(opErr.Description, resourceNotFoundCode)
+}
+
+// Bytes is a byte array, encoded in DMR JSON as {"BYTES_VALUE": "<base64>"}
+type Bytes []byte
+
+// MarshalJSON encodes the bytes as a BYTES_VALUE object
+func (b Bytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"BYTES_VALUE": base64.StdEncoding.EncodeToString(b)})
+}
+
+// UnmarshalJSON decodes a BYTES_VALUE object
+func (b *Bytes) UnmarshalJSON(data []byte) error {
+	var v map[string]string
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	decoded, err := base64.StdEncoding.DecodeString(v["BYTES_VALUE"])
+	if err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
+
+// deploymentContent is an item of the content attribute of a deployment
+type deploymentContent struct {
+	Hash Bytes `json:"hash"`
+}
+
+// DeploymentAddress returns the address of a deployment
+func DeploymentAddress(name string) Address {
+	return NewAddress("deployment", name)
+}
+
+// DeploymentStatus returns the status of a deployment. The error satisfies IsNotFound when
+// the deployment does not exist.
+func (c *Client) DeploymentStatus(name string) (string, error) {
+	var status string
+	err := c.ReadAttribute(DeploymentAddress(name), "status", &status)
+	return status, err
+}
+
+// DeploymentContentHash returns the SHA-1 hash of the content of a deployment, as computed
+// by the content repository. The error satisfies IsNotFound when the deployment does not exist.
+func (c *Client) DeploymentContentHash(name string) ([]byte, error) {
+	var content []deploymentContent
+	err := c.ReadAttribute(DeploymentAddress(name), "content", &content)
+	if err != nil || len(content) == 0 {
+		return nil, err
+	}
+	return content[0].Hash, nil
+}
+
+// UploadContent stores an artifact in the content repository of the server through the
+// add-content endpoint and returns its hash
+func (c *Client) UploadContent(name string, content []byte) ([]byte, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("file", name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(content); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	result, err := c.send(c.URL+"/add-content", w.FormDataContentType(), body.Bytes(), "add-content")
+	if err != nil {
+		return nil, err
+	}
+	var hash Bytes
+	if err := json.Unmarshal(result.Result, &hash); err != nil {
+		return nil, err
+	}
+	return hash, nil
+}
+
+// Deploy deploys the content of the repository with the given hash under name, replacing the
+// content of an existing deployment with the same name.
+func (c *Client) Deploy(name string, hash []byte) error {
+	content := []deploymentContent{{Hash: hash}}
+	_, err := c.DeploymentStatus(name)
+	if IsNotFound(err) {
+		op := NewOperation("add", DeploymentAddress(name))
+		op.Parameters["content"] = content
+		op.Parameters["enabled"] = true
+		_, err = c.Execute(op)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	op := NewOperation("full-replace-deployment", Address{})
+	op.Parameters["name"] = name
+	op.Parameters["content"] = content
+	op.Parameters["enabled"] = true
+	_, err = c.Execute(op)
+	return err
+}
+
+// Undeploy undeploys and removes a deployment, a missing deployment is not an error
+func (c *Client) Undeploy(name string) error {
+	_, err := c.Execute(NewOperation("remove", DeploymentAddress(name)))
+	if IsNotFound(err) {
+		return nil
+	}
+	return err
+}
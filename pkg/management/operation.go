@@ -0,0 +1,139 @@
+package management
+
+import (
+	"encoding/json"
+)
+
+// Common operation outcomes returned by the server
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailed  = "failed"
+)
+
+// AddressElement is a key/value pair identifying a resource in the management model,
+// e.g. subsystem=undertow.
+type AddressElement struct {
+	Key   string
+	Value string
+}
+
+// MarshalJSON encodes the element as a single property object, e.g. {"subsystem":"undertow"}
+func (e AddressElement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{e.Key: e.Value})
+}
+
+// Address is the path of a resource in the management model. The empty address is the
+// root resource of the server.
+type Address []AddressElement
+
+// NewAddress builds an Address from a flat list of key/value strings, e.g.
+// NewAddress("subsystem", "datasources", "data-source", "ExampleDS"). A trailing key
+// without value is ignored.
+func NewAddress(pairs ...string) Address {
+	address := Address{}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		address = append(address, AddressElement{Key: pairs[i], Value: pairs[i+1]})
+	}
+	return address
+}
+
+// MarshalJSON always encodes the address as an array, the root address included
+func (a Address) MarshalJSON() ([]byte, error) {
+	if a == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]AddressElement(a))
+}
+
+// Operation is a management operation executed against a resource
+type Operation struct {
+	Name       string
+	Address    Address
+	Parameters map[string]interface{}
+}
+
+// MarshalJSON encodes the operation in the DMR JSON format, where the parameters are
+// flattened next to the operation name and the address.
+func (o Operation) MarshalJSON() ([]byte, error) {
+	m := map[string]interface{}{}
+	for k, v := range o.Parameters {
+		m[k] = v
+	}
+	m["operation"] = o.Name
+	m["address"] = o.Address
+	return json.Marshal(m)
+}
+
+// NewOperation returns an operation without parameters
+func NewOperation(name string, address Address) Operation {
+	return Operation{Name: name, Address: address, Parameters: map[string]interface{}{}}
+}
+
+// ReadAttribute returns a read-attribute operation for the named attribute
+func ReadAttribute(address Address, name string) Operation {
+	op := NewOperation("read-attribute", address)
+	op.Parameters["name"] = name
+	return op
+}
+
+// WriteAttribute returns a write-attribute operation setting the named attribute
+func WriteAttribute(address Address, name string, value interface{}) Operation {
+	op := NewOperation("write-attribute", address)
+	op.Parameters["name"] = name
+	op.Parameters["value"] = value
+	return op
+}
+
+// ReadResource returns a read-resource operation, optionally recursive and including
+// runtime attributes.
+func ReadResource(address Address, recursive, includeRuntime bool) Operation {
+	op := NewOperation("read-resource", address)
+	op.Parameters["recursive"] = recursive
+	op.Parameters["include-runtime"] = includeRuntime
+	return op
+}
+
+// Composite returns an operation executing all the steps atomically
+func Composite(steps ...Operation) Operation {
+	op := NewOperation("composite", Address{})
+	op.Parameters["steps"] = steps
+	return op
+}
+
+// Result is the response of the server to an operation
+type Result struct {
+	Outcome            string          `json:"outcome"`
+	Result             json.RawMessage `json:"result,omitempty"`
+	FailureDescription json.RawMessage `json:"failure-description,omitempty"`
+	RolledBack         bool            `json:"rolled-back,omitempty"`
+	ResponseHeaders    json.RawMessage `json:"response-headers,omitempty"`
+}
+
+// Success reports whether the operation succeeded
+func (r *Result) Success() bool {
+	return r.Outcome == OutcomeSuccess
+}
+
+// OperationError is returned when the server reports a failed outcome
+type OperationError struct {
+	Operation   string
+	Description string
+}
+
+func (e *OperationError) Error() string {
+	return "management operation " + e.Operation + " failed: " + e.Description
+}
+
+// UndefineAttribute returns an undefine-attribute operation resetting the named attribute
+func UndefineAttribute(address Address, name string) Operation {
+	op := NewOperation("undefine-attribute", address)
+	op.Parameters["name"] = name
+	return op
+}
+
+// AllowResourceServiceRestart sets the operation header applying the operation at runtime by
+// restarting the affected services, instead of putting the server in reload-required state
+func AllowResourceServiceRestart(op Operation) Operation {
+	op.Parameters["operation-headers"] = map[string]interface{}{"allow-resource-service-restart": true}
+	return op
+}
@@ -0,0 +1,65 @@
+package management
+
+import (
+	"encoding/json"
+)
+
+// Suspend states reported by the suspend-state attribute of the root resource
+const (
+	SuspendStateRunning    = "RUNNING"
+	SuspendStatePreSuspend = "PRE_SUSPEND"
+	SuspendStateSuspending = "SUSPENDING"
+	SuspendStateSuspended  = "SUSPENDED"
+)
+
+// transactionsLogStore is the address of the transaction log store
+var transactionsLogStore = NewAddress("subsystem", "transactions", "log-store", "log-store")
+
+// Reload reloads the server, applying the configuration changes that require it
+func (c *Client) Reload() error {
+	_, err := c.Execute(NewOperation("reload", Address{}))
+	return err
+}
+
+// Suspend stops the server from accepting new requests and lets the active ones complete.
+// The operation returns immediately when timeout is 0, the server then keeps suspending in
+// the background; a negative timeout waits for all the requests to complete.
+func (c *Client) Suspend(timeout int) error {
+	op := NewOperation("suspend", Address{})
+	op.Parameters["timeout"] = timeout
+	_, err := c.Execute(op)
+	return err
+}
+
+// Resume resumes a suspended server
+func (c *Client) Resume() error {
+	_, err := c.Execute(NewOperation("resume", Address{}))
+	return err
+}
+
+// SuspendState returns the suspend-state of the server
+func (c *Client) SuspendState() (string, error) {
+	var state string
+	err := c.ReadAttribute(Address{}, "suspend-state", &state)
+	return state, err
+}
+
+// Transactions refreshes the transaction log store from the object store and returns the ids
+// of the transactions it holds, e.g. in-doubt or heuristically completed ones.
+func (c *Client) Transactions() ([]string, error) {
+	_, err := c.Execute(NewOperation("probe", transactionsLogStore))
+	if err != nil {
+		return nil, err
+	}
+	op := NewOperation("read-children-names", transactionsLogStore)
+	op.Parameters["child-type"] = "transactions"
+	result, err := c.Execute(op)
+	if err != nil {
+		return nil, err
+	}
+	ids := []string{}
+	if err := json.Unmarshal(result.Result, &ids); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}